@@ -0,0 +1,124 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/grpcerr"
+	"github.com/tenteedee/mini-uber/shared/validation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// writeError writes an error in the contracts.APIResponse envelope.
+func writeError(w http.ResponseWriter, statusCode int, code string, message string) {
+	if err := writeJSON(w, statusCode, contracts.APIResponse{
+		Error: &contracts.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}); err != nil {
+		log.Printf("failed to write error response: %v", err)
+	}
+}
+
+// writeValidationError reports every invalid request field to the client.
+func writeValidationError(w http.ResponseWriter, fieldErrors []validation.FieldError) {
+	writeJSON(w, http.StatusBadRequest, contracts.APIResponse{
+		Error: &contracts.APIError{
+			Code:    contracts.ErrCodeValidationFailed,
+			Message: "request validation failed",
+			Fields:  toAPIFieldErrors(fieldErrors),
+		},
+	})
+}
+
+func toAPIFieldErrors(fieldErrors []validation.FieldError) []contracts.APIFieldError {
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+
+	apiErrors := make([]contracts.APIFieldError, len(fieldErrors))
+	for i, fieldErr := range fieldErrors {
+		apiErrors[i] = contracts.APIFieldError{
+			Field:   fieldErr.Field,
+			Message: fieldErr.Message,
+		}
+	}
+	return apiErrors
+}
+
+// writeGRPCError translates an error returned by a downstream gRPC service into an API error.
+// The reason attached by the service is used as the error code, otherwise one is derived from the gRPC code.
+func writeGRPCError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	statusCode := httpStatusFromGRPC(st.Code())
+
+	code := grpcerr.Reason(err)
+	if code == "" {
+		code = errorCodeFromGRPC(st.Code())
+	}
+
+	message := st.Message()
+	if statusCode == http.StatusInternalServerError {
+		// don't leak internal details of unexpected failures to clients
+		message = "internal error"
+	}
+
+	writeJSON(w, statusCode, contracts.APIResponse{
+		Error: &contracts.APIError{
+			Code:    code,
+			Message: message,
+			Fields:  toAPIFieldErrors(grpcerr.FieldErrors(err)),
+		},
+	})
+}
+
+// httpStatusFromGRPC maps a gRPC code returned by a downstream service to the HTTP status sent to the client.
+func httpStatusFromGRPC(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorCodeFromGRPC is the fallback API error code for gRPC errors without a reason.
+func errorCodeFromGRPC(code codes.Code) string {
+	switch code {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return contracts.ErrCodeInvalidRequest
+	case codes.Unauthenticated:
+		return contracts.ErrCodeUnauthenticated
+	case codes.PermissionDenied:
+		return contracts.ErrCodePermissionDenied
+	case codes.NotFound:
+		return contracts.ErrCodeNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return contracts.ErrCodeConflict
+	case codes.ResourceExhausted:
+		return contracts.ErrCodeRateLimited
+	case codes.Unavailable:
+		return contracts.ErrCodeUnavailable
+	case codes.DeadlineExceeded:
+		return contracts.ErrCodeTimeout
+	default:
+		return contracts.ErrCodeInternal
+	}
+}
@@ -0,0 +1,92 @@
+package grpcclients
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc"
+	// registers the client side health checking function used by healthCheckConfig
+	_ "google.golang.org/grpc/health"
+)
+
+// serviceConfig describes the gRPC service config used by the gateway clients, see
+// https://github.com/grpc/grpc/blob/master/doc/service_config.md
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+	HealthCheckConfig   healthCheckConfig     `json:"healthCheckConfig"`
+	MethodConfig        []methodConfig        `json:"methodConfig,omitempty"`
+}
+
+type healthCheckConfig struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	RetryPolicy retryPolicy  `json:"retryPolicy"`
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// newServiceConfig balances calls round robin over every resolved replica, only
+// picks replicas whose health service reports SERVING, and retries the given
+// idempotent methods when a replica is unavailable.
+// Non idempotent methods must not be listed, as a retry could run them twice.
+func newServiceConfig(service string, idempotentMethods ...string) string {
+	cfg := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{"round_robin": {}}},
+		HealthCheckConfig:   healthCheckConfig{ServiceName: service},
+	}
+
+	if len(idempotentMethods) > 0 {
+		names := make([]methodName, len(idempotentMethods))
+		for i, method := range idempotentMethods {
+			names[i] = methodName{Service: service, Method: method}
+		}
+
+		cfg.MethodConfig = []methodConfig{{
+			Name: names,
+			RetryPolicy: retryPolicy{
+				MaxAttempts:          4,
+				InitialBackoff:       "0.1s",
+				MaxBackoff:           "1s",
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"UNAVAILABLE"},
+			},
+		}}
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		// the config is built from static values only
+		panic(err)
+	}
+
+	return string(b)
+}
+
+// withDefaultTimeout sets a deadline on every call that doesn't already have one,
+// so a hanging backend can't hold gateway requests forever.
+func withDefaultTimeout(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -1,8 +1,9 @@
 package grpcclients
 
 import (
-	"os"
+	"time"
 
+	"github.com/tenteedee/mini-uber/shared/env"
 	pb "github.com/tenteedee/mini-uber/shared/proto/driver"
 	"github.com/tenteedee/mini-uber/shared/tracing"
 	"google.golang.org/grpc"
@@ -14,15 +15,19 @@ type DriverServiceClient struct {
 	conn   *grpc.ClientConn
 }
 
+// NewDriverServiceClient creates the connection to the driver service. It is meant to be
+// created once at startup and shared by all handlers, the connection is safe for concurrent use.
 func NewDriverServiceClient() (*DriverServiceClient, error) {
-	driverServiceURL := os.Getenv("DRIVER_SERVICE_URL")
-	if driverServiceURL == "" {
-		driverServiceURL = "driver-service:9092"
-	}
+	// the dns resolver returns every replica behind the (headless) service so calls can be balanced between them
+	driverServiceURL := env.GetString("DRIVER_SERVICE_URL", "dns:///driver-service:9092")
+	timeout := time.Duration(env.GetInt("DRIVER_SERVICE_TIMEOUT_MS", 5000)) * time.Millisecond
 
 	dialOptions := append(
 		tracing.DialOptionsWithTracing(),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// RegisterDriver adds the driver to the pool again on every call, only unregistering is safe to retry
+		grpc.WithDefaultServiceConfig(newServiceConfig(pb.DriverService_ServiceDesc.ServiceName, "UnregisterDriver")),
+		grpc.WithUnaryInterceptor(withDefaultTimeout(timeout)),
 	)
 
 	conn, err := grpc.NewClient(driverServiceURL, dialOptions...)
@@ -0,0 +1,52 @@
+package grpcclients
+
+import (
+	"time"
+
+	"github.com/tenteedee/mini-uber/shared/env"
+	pb "github.com/tenteedee/mini-uber/shared/proto/payment"
+	"github.com/tenteedee/mini-uber/shared/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type PaymentServiceClient struct {
+	Client pb.PaymentServiceClient
+	conn   *grpc.ClientConn
+}
+
+// NewPaymentServiceClient creates the connection to the payment service, shared by all handlers
+func NewPaymentServiceClient() (*PaymentServiceClient, error) {
+	paymentServiceURL := env.GetString("PAYMENT_SERVICE_URL", "dns:///payment-service:9004")
+	timeout := time.Duration(env.GetInt("PAYMENT_SERVICE_TIMEOUT_MS", 5000)) * time.Millisecond
+
+	dialOptions := append(
+		tracing.DialOptionsWithTracing(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// refunds move money, only the reads are safe to retry
+		grpc.WithDefaultServiceConfig(newServiceConfig(pb.PaymentService_ServiceDesc.ServiceName,
+			"GetPayment", "ListPaymentsForTrip", "GetDriverEarnings")),
+		grpc.WithUnaryInterceptor(withDefaultTimeout(timeout)),
+	)
+
+	conn, err := grpc.NewClient(paymentServiceURL, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := pb.NewPaymentServiceClient(conn)
+
+	return &PaymentServiceClient{
+		Client: client,
+		conn:   conn,
+	}, nil
+}
+
+func (c *PaymentServiceClient) Close() error {
+	if c.conn != nil {
+		if err := c.conn.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,8 +1,9 @@
 package grpcclients
 
 import (
-	"os"
+	"time"
 
+	"github.com/tenteedee/mini-uber/shared/env"
 	pb "github.com/tenteedee/mini-uber/shared/proto/trip"
 	"github.com/tenteedee/mini-uber/shared/tracing"
 	"google.golang.org/grpc"
@@ -14,15 +15,19 @@ type TripServiceClient struct {
 	conn   *grpc.ClientConn
 }
 
+// NewTripServiceClient creates the connection to the trip service. It is meant to be
+// created once at startup and shared by all handlers, the connection is safe for concurrent use.
 func NewTripServiceClient() (*TripServiceClient, error) {
-	tripServiceURL := os.Getenv("TRIP_SERVICE_URL")
-	if tripServiceURL == "" {
-		tripServiceURL = "trip-service:9093"
-	}
+	// the dns resolver returns every replica behind the (headless) service so calls can be balanced between them
+	tripServiceURL := env.GetString("TRIP_SERVICE_URL", "dns:///trip-service:9093")
+	timeout := time.Duration(env.GetInt("TRIP_SERVICE_TIMEOUT_MS", 10000)) * time.Millisecond
 
 	dialOptions := append(
 		tracing.DialOptionsWithTracing(),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// PreviewTrip only stores fares that are never used if the response is lost, CreateTrip must not be retried
+		grpc.WithDefaultServiceConfig(newServiceConfig(pb.TripService_ServiceDesc.ServiceName, "PreviewTrip")),
+		grpc.WithUnaryInterceptor(withDefaultTimeout(timeout)),
 	)
 
 	conn, err := grpc.NewClient(tripServiceURL, dialOptions...)
@@ -2,50 +2,42 @@ package main
 
 import (
 	"encoding/json"
-	"io"
 	"log"
 	"net/http"
 
-	"github.com/stripe/stripe-go/v81"
-	"github.com/stripe/stripe-go/v81/webhook"
 	grpcclients "github.com/tenteedee/mini-uber/services/api-gateway/grpc_clients"
 	"github.com/tenteedee/mini-uber/shared/contracts"
-	"github.com/tenteedee/mini-uber/shared/env"
 	"github.com/tenteedee/mini-uber/shared/messaging"
+	paymentpb "github.com/tenteedee/mini-uber/shared/proto/payment"
 	"github.com/tenteedee/mini-uber/shared/tracing"
+	"github.com/tenteedee/mini-uber/shared/validation"
 )
 
 var (
 	tracer = tracing.GetTracer("api-gateway")
 )
 
-func handleTripPreview(w http.ResponseWriter, r *http.Request) {
+func handleTripPreview(w http.ResponseWriter, r *http.Request, tripService *grpcclients.TripServiceClient) {
 	ctx, span := tracer.Start(r.Context(), "handleTripPreview")
 	defer span.End()
 
 	var requestBody previewTripRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
-	if requestBody.UserId == "" {
-		http.Error(w, "Missing user_id", http.StatusBadRequest)
+	if fieldErrors := requestBody.Validate(); len(fieldErrors) > 0 {
+		writeValidationError(w, fieldErrors)
 		return
 	}
 
-	tripService, err := grpcclients.NewTripServiceClient()
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer tripService.Close()
-
 	tripPreview, err := tripService.Client.PreviewTrip(ctx, requestBody.ToProto())
 	if err != nil {
 		log.Printf("Failed to preview trip: %v", err)
-		http.Error(w, "Failed to preview trip: "+err.Error(), http.StatusInternalServerError)
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -57,28 +49,27 @@ func handleTripPreview(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func handleTripStart(w http.ResponseWriter, r *http.Request) {
+func handleTripStart(w http.ResponseWriter, r *http.Request, tripService *grpcclients.TripServiceClient) {
 	ctx, span := tracer.Start(r.Context(), "handleTripStart")
 	defer span.End()
 
 	var reqBody startTripRequest
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-		http.Error(w, "failed to parse JSON data", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidRequest, "failed to parse JSON data")
 		return
 	}
 
 	defer r.Body.Close()
 
-	tripService, err := grpcclients.NewTripServiceClient()
-	if err != nil {
-		log.Fatal(err)
+	if fieldErrors := reqBody.Validate(); len(fieldErrors) > 0 {
+		writeValidationError(w, fieldErrors)
+		return
 	}
-	defer tripService.Close()
 
 	trip, err := tripService.Client.CreateTrip(ctx, reqBody.toProto())
 	if err != nil {
 		log.Printf("Failed to start a trip: %v", err)
-		http.Error(w, "Failed to start trip", http.StatusInternalServerError)
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -87,76 +78,187 @@ func handleTripStart(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, response)
 }
 
-func handleStripeWebhook(w http.ResponseWriter, r *http.Request, rb *messaging.RabbitMQ) {
-	ctx, span := tracer.Start(r.Context(), "handleStripeWebhook")
+func handleTripTip(w http.ResponseWriter, r *http.Request, tripService *grpcclients.TripServiceClient) {
+	ctx, span := tracer.Start(r.Context(), "handleTripTip")
+	defer span.End()
+
+	var reqBody tipTripRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidRequest, "failed to parse JSON data")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if fieldErrors := reqBody.Validate(); len(fieldErrors) > 0 {
+		writeValidationError(w, fieldErrors)
+		return
+	}
+
+	tip, err := tripService.Client.TipTrip(ctx, reqBody.toProto())
+	if err != nil {
+		log.Printf("Failed to tip trip %s: %v", reqBody.TripID, err)
+		writeGRPCError(w, err)
+		return
+	}
+
+	// the tip is charged asynchronously, the rider gets its checkout session over the websocket
+	response := contracts.APIResponse{Data: tip}
+
+	writeJSON(w, http.StatusAccepted, response)
+}
+
+func handleDriverEarnings(w http.ResponseWriter, r *http.Request, paymentService *grpcclients.PaymentServiceClient) {
+	ctx, span := tracer.Start(r.Context(), "handleDriverEarnings")
+	defer span.End()
+
+	reqQuery, fieldErrors := parseDriverEarningsRequest(r.URL.Query())
+	if len(fieldErrors) > 0 {
+		writeValidationError(w, fieldErrors)
+		return
+	}
+
+	earnings, err := paymentService.Client.GetDriverEarnings(ctx, reqQuery.toProto())
+	if err != nil {
+		log.Printf("Failed to get earnings of driver %s: %v", reqQuery.UserID, err)
+		writeGRPCError(w, err)
+		return
+	}
+
+	response := contracts.APIResponse{Data: driverEarningsFromProto(earnings)}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handleSetupPaymentMethod(w http.ResponseWriter, r *http.Request, paymentService *grpcclients.PaymentServiceClient) {
+	ctx, span := tracer.Start(r.Context(), "handleSetupPaymentMethod")
+	defer span.End()
+
+	var reqBody setupPaymentMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidRequest, "failed to parse JSON data")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if fieldErrors := reqBody.Validate(); len(fieldErrors) > 0 {
+		writeValidationError(w, fieldErrors)
+		return
+	}
+
+	session, err := paymentService.Client.CreateSetupSession(ctx, &paymentpb.CreateSetupSessionRequest{
+		UserID: reqBody.UserID,
+	})
+	if err != nil {
+		log.Printf("Failed to create setup session for rider %s: %v", reqBody.UserID, err)
+		writeGRPCError(w, err)
+		return
+	}
+
+	// the method is saved once the rider completes the setup page, it shows up in the list afterwards
+	response := contracts.APIResponse{Data: setupSessionResponse{
+		SessionID: session.GetSessionID(),
+		SetupURL:  session.GetSetupURL(),
+	}}
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+func handleListPaymentMethods(w http.ResponseWriter, r *http.Request, paymentService *grpcclients.PaymentServiceClient) {
+	ctx, span := tracer.Start(r.Context(), "handleListPaymentMethods")
 	defer span.End()
 
-	body, err := io.ReadAll(r.Body)
+	userID := r.URL.Query().Get("userID")
+	v := validation.New()
+	v.Required("userID", userID)
+	if !v.Valid() {
+		writeValidationError(w, v.Errors())
+		return
+	}
+
+	methods, err := paymentService.Client.ListPaymentMethods(ctx, &paymentpb.ListPaymentMethodsRequest{
+		UserID: userID,
+	})
 	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusInternalServerError)
+		log.Printf("Failed to list payment methods of rider %s: %v", userID, err)
+		writeGRPCError(w, err)
 		return
 	}
+
+	response := contracts.APIResponse{Data: paymentMethodsFromProto(methods)}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handleSetDefaultPaymentMethod(w http.ResponseWriter, r *http.Request, paymentService *grpcclients.PaymentServiceClient) {
+	ctx, span := tracer.Start(r.Context(), "handleSetDefaultPaymentMethod")
+	defer span.End()
+
+	var reqBody defaultPaymentMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidRequest, "failed to parse JSON data")
+		return
+	}
+
 	defer r.Body.Close()
 
-	webhookKey := env.GetString("STRIPE_WEBHOOK_KEY", "")
-	if webhookKey == "" {
-		log.Printf("Webhook key is required")
+	if fieldErrors := reqBody.Validate(); len(fieldErrors) > 0 {
+		writeValidationError(w, fieldErrors)
 		return
 	}
 
-	event, err := webhook.ConstructEventWithOptions(
-		body,
-		r.Header.Get("Stripe-Signature"),
-		webhookKey,
-		webhook.ConstructEventOptions{
-			IgnoreAPIVersionMismatch: true,
-		},
-	)
+	methods, err := paymentService.Client.SetDefaultPaymentMethod(ctx, &paymentpb.SetDefaultPaymentMethodRequest{
+		UserID:          reqBody.UserID,
+		PaymentMethodID: reqBody.PaymentMethodID,
+	})
 	if err != nil {
-		log.Printf("Error verifying webhook signature: %v", err)
-		http.Error(w, "Invalid signature", http.StatusBadRequest)
-		return
-	}
-
-	log.Printf("Received Stripe event: %v", event)
-
-	switch event.Type {
-	case "checkout.session.completed":
-		var session stripe.CheckoutSession
-
-		err := json.Unmarshal(event.Data.Raw, &session)
-		if err != nil {
-			log.Printf("Error parsing webhook JSON: %v", err)
-			http.Error(w, "Invalid payload", http.StatusBadRequest)
-			return
-		}
-
-		payload := messaging.PaymentStatusUpdateData{
-			TripID:   session.Metadata["trip_id"],
-			UserID:   session.Metadata["user_id"],
-			DriverID: session.Metadata["driver_id"],
-		}
-
-		payloadBytes, err := json.Marshal(payload)
-		if err != nil {
-			log.Printf("Error marshalling payload: %v", err)
-			http.Error(w, "Failed to marshal payload", http.StatusInternalServerError)
-			return
-		}
-
-		message := contracts.AmqpMessage{
-			OwnerID: session.Metadata["user_id"],
-			Data:    payloadBytes,
-		}
-
-		if err := rb.PublishMessage(
-			ctx,
-			contracts.PaymentEventSuccess,
-			message,
-		); err != nil {
-			log.Printf("Error publishing payment event: %v", err)
-			http.Error(w, "Failed to publish payment event", http.StatusInternalServerError)
-			return
-		}
+		log.Printf("Failed to set default payment method of rider %s: %v", reqBody.UserID, err)
+		writeGRPCError(w, err)
+		return
+	}
+
+	response := contracts.APIResponse{Data: paymentMethodsFromProto(methods)}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handleRemovePaymentMethod(w http.ResponseWriter, r *http.Request, paymentService *grpcclients.PaymentServiceClient) {
+	ctx, span := tracer.Start(r.Context(), "handleRemovePaymentMethod")
+	defer span.End()
+
+	userID := r.URL.Query().Get("userID")
+	paymentMethodID := r.PathValue("id")
+	v := validation.New()
+	v.Required("userID", userID)
+	v.Required("id", paymentMethodID)
+	if !v.Valid() {
+		writeValidationError(w, v.Errors())
+		return
 	}
+
+	methods, err := paymentService.Client.RemovePaymentMethod(ctx, &paymentpb.RemovePaymentMethodRequest{
+		UserID:          userID,
+		PaymentMethodID: paymentMethodID,
+	})
+	if err != nil {
+		log.Printf("Failed to remove payment method %s of rider %s: %v", paymentMethodID, userID, err)
+		writeGRPCError(w, err)
+		return
+	}
+
+	response := contracts.APIResponse{Data: paymentMethodsFromProto(methods)}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleHealth fails while RabbitMQ is reconnecting, the websockets get no events meanwhile
+func handleHealth(w http.ResponseWriter, r *http.Request, rabbitmq *messaging.RabbitMQ) {
+	state := rabbitmq.State()
+	status := http.StatusOK
+	if state != messaging.StateConnected {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, map[string]string{"rabbitmq": string(state)})
 }
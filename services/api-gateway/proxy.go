@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/tenteedee/mini-uber/shared/contracts"
+)
+
+// newWebhookProxy forwards payment processor webhooks to the payment service untouched,
+// the raw body and signature headers are needed there to verify them.
+func newWebhookProxy(target string) (http.HandlerFunc, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("Failed to proxy webhook to payment service: %v", err)
+		writeError(w, http.StatusBadGateway, contracts.ErrCodeUnavailable, "payment service is unavailable")
+	}
+
+	return proxy.ServeHTTP, nil
+}
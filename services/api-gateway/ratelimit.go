@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/env"
+)
+
+const (
+	// idle buckets are dropped after this long so the map does not grow forever
+	bucketIdleTTL = 10 * time.Minute
+	// the rate limited routes take small JSON bodies, a larger one is refused before it is buffered
+	maxRequestBodyBytes = 64 << 10
+)
+
+// RateLimitConfig describes a token bucket: RequestsPerMinute tokens are refilled
+// every minute and at most Burst requests can be made back to back.
+type RateLimitConfig struct {
+	RequestsPerMinute int
+	Burst             int
+}
+
+// rateLimitConfigFromEnv reads <prefix>_RPM and <prefix>_BURST, e.g. RATE_LIMIT_TRIP_PREVIEW_RPM.
+func rateLimitConfigFromEnv(prefix string, fallback RateLimitConfig) RateLimitConfig {
+	return RateLimitConfig{
+		RequestsPerMinute: env.GetInt(prefix+"_RPM", fallback.RequestsPerMinute),
+		Burst:             env.GetInt(prefix+"_BURST", fallback.Burst),
+	}
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// rateLimiter is an in-memory token bucket limiter keyed by an arbitrary string.
+// Note that on multiple instances of the API gateway every instance keeps its own buckets.
+type rateLimiter struct {
+	ratePerSec float64
+	burst      float64
+	buckets    map[string]*tokenBucket
+	lastSweep  time.Time
+	mutex      sync.Mutex
+}
+
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	burst := cfg.Burst
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		ratePerSec: float64(cfg.RequestsPerMinute) / 60,
+		burst:      float64(burst),
+		buckets:    make(map[string]*tokenBucket),
+		lastSweep:  time.Now(),
+	}
+}
+
+// allow takes a token for each of the keys, only when none of their buckets is empty.
+// Otherwise it takes none and returns false together with the time until every
+// bucket has a token again.
+func (l *rateLimiter) allow(keys ...string) (bool, time.Duration) {
+	// a non-positive rate disables the limiter
+	if l.ratePerSec <= 0 {
+		return true, 0
+	}
+
+	now := time.Now()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.sweep(now)
+
+	buckets := make([]*tokenBucket, len(keys))
+	var wait time.Duration
+	for i, key := range keys {
+		bucket, exists := l.buckets[key]
+		if !exists {
+			bucket = &tokenBucket{tokens: l.burst, lastSeen: now}
+			l.buckets[key] = bucket
+		}
+
+		elapsed := now.Sub(bucket.lastSeen).Seconds()
+		bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed*l.ratePerSec)
+		bucket.lastSeen = now
+		buckets[i] = bucket
+
+		if bucket.tokens < 1 {
+			wait = max(wait, time.Duration((1-bucket.tokens)/l.ratePerSec*float64(time.Second)))
+		}
+	}
+
+	if wait > 0 {
+		return false, wait
+	}
+
+	for _, bucket := range buckets {
+		bucket.tokens--
+	}
+	return true, 0
+}
+
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketIdleTTL {
+		return
+	}
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) > bucketIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// rateLimit applies two limits: one per client IP shared by all routes, and a
+// route specific one per client IP and per user. The user ID is whatever the
+// client sent until the gateway authenticates its callers, so the route limit
+// also holds per IP: a client rotating user IDs doesn't get fresh buckets.
+// Users behind the same address share that route limit.
+func rateLimit(ipLimiter, routeLimiter *rateLimiter, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+
+		if ok, wait := ipLimiter.allow("ip:" + ip); !ok {
+			writeRateLimited(w, wait)
+			return
+		}
+
+		userID, err := userIDFromRequest(w, r)
+		if err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, contracts.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+
+		keys := []string{"ip:" + ip}
+		if userID != "" {
+			keys = append(keys, "user:"+userID)
+		}
+
+		if ok, wait := routeLimiter.allow(keys...); !ok {
+			writeRateLimited(w, wait)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func writeRateLimited(w http.ResponseWriter, wait time.Duration) {
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeError(w, http.StatusTooManyRequests, contracts.ErrCodeRateLimited, fmt.Sprintf("too many requests, retry in %d seconds", retryAfter))
+}
+
+var trustProxyHeaders = env.GetBool("TRUST_PROXY_HEADERS", false)
+
+// clientIP returns the address of the caller. X-Forwarded-For is only honoured
+// when the gateway runs behind a trusted proxy, otherwise it can be spoofed.
+func clientIP(r *http.Request) string {
+	if trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// userIDFromRequest finds the caller's user ID in the query string or in the
+// JSON body. The body is restored so handlers can still decode it, it fails
+// when the body is larger than maxRequestBodyBytes.
+func userIDFromRequest(w http.ResponseWriter, r *http.Request) (string, error) {
+	if userID := r.URL.Query().Get("userID"); userID != "" {
+		return userID, nil
+	}
+
+	// the web client doesn't always set a JSON content type, so don't rely on it
+	if r.Body == nil || r.Body == http.NoBody {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return "", fmt.Errorf("request body is larger than %d bytes", tooLarge.Limit)
+		}
+		return "", nil
+	}
+
+	var identity struct {
+		UserID string `json:"userId"`
+	}
+	if err := json.Unmarshal(body, &identity); err != nil {
+		return "", nil
+	}
+
+	return identity.UserID, nil
+}
+
+// connectionLimiter caps the number of concurrent websocket connections per user.
+type connectionLimiter struct {
+	max    int
+	active map[string]int
+	mutex  sync.Mutex
+}
+
+func newConnectionLimiter(max int) *connectionLimiter {
+	return &connectionLimiter{
+		max:    max,
+		active: make(map[string]int),
+	}
+}
+
+// acquire reserves a connection slot for the user, release must be called once the connection is closed.
+func (l *connectionLimiter) acquire(userID string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.max > 0 && l.active[userID] >= l.max {
+		return false
+	}
+
+	l.active[userID]++
+	return true
+}
+
+func (l *connectionLimiter) release(userID string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.active[userID]--
+	if l.active[userID] <= 0 {
+		delete(l.active, userID)
+	}
+}
@@ -1,14 +1,28 @@
 package main
 
 import (
+	"net/url"
+	"time"
+
+	paymentpb "github.com/tenteedee/mini-uber/shared/proto/payment"
 	pb "github.com/tenteedee/mini-uber/shared/proto/trip"
 	"github.com/tenteedee/mini-uber/shared/types"
+	"github.com/tenteedee/mini-uber/shared/validation"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type previewTripRequest struct {
-	UserId      string           `json:"userId"`
-	Pickup      types.Coordinate `json:"pickup"`
-	Destination types.Coordinate `json:"destination"`
+	UserId      string            `json:"userId"`
+	Pickup      *types.Coordinate `json:"pickup"`
+	Destination *types.Coordinate `json:"destination"`
+}
+
+func (p *previewTripRequest) Validate() []validation.FieldError {
+	v := validation.New()
+	v.Required("userId", p.UserId)
+	v.Trip("pickup", p.Pickup, "destination", p.Destination, float64(maxTripDistanceKm*1000))
+	return v.Errors()
 }
 
 func (p *previewTripRequest) ToProto() *pb.PreviewTripRequest {
@@ -30,9 +44,231 @@ type startTripRequest struct {
 	UserID     string `json:"userId"`
 }
 
+func (c *startTripRequest) Validate() []validation.FieldError {
+	v := validation.New()
+	v.Required("rideFareId", c.RideFareID)
+	v.Required("userId", c.UserID)
+	return v.Errors()
+}
+
 func (c *startTripRequest) toProto() *pb.CreateTripRequest {
 	return &pb.CreateTripRequest{
 		RideFareID: c.RideFareID,
 		UserID:     c.UserID,
 	}
 }
+
+type tipTripRequest struct {
+	TripID string      `json:"tripId"`
+	UserID string      `json:"userId"`
+	Amount types.Money `json:"amount"`
+}
+
+func (t *tipTripRequest) Validate() []validation.FieldError {
+	v := validation.New()
+	v.Required("tripId", t.TripID)
+	v.Required("userId", t.UserID)
+	v.Required("amount.currency", t.Amount.Currency)
+	if t.Amount.Amount <= 0 {
+		v.AddError("amount.amount", "must be positive")
+	}
+	return v.Errors()
+}
+
+func (t *tipTripRequest) toProto() *pb.TipTripRequest {
+	return &pb.TipTripRequest{
+		TripID: t.TripID,
+		UserID: t.UserID,
+		Amount: types.NewMoney(t.Amount.Amount, t.Amount.Currency).ToProto(),
+	}
+}
+
+type setupPaymentMethodRequest struct {
+	UserID string `json:"userId"`
+}
+
+func (s *setupPaymentMethodRequest) Validate() []validation.FieldError {
+	v := validation.New()
+	v.Required("userId", s.UserID)
+	return v.Errors()
+}
+
+type defaultPaymentMethodRequest struct {
+	UserID          string `json:"userId"`
+	PaymentMethodID string `json:"paymentMethodId"`
+}
+
+func (d *defaultPaymentMethodRequest) Validate() []validation.FieldError {
+	v := validation.New()
+	v.Required("userId", d.UserID)
+	v.Required("paymentMethodId", d.PaymentMethodID)
+	return v.Errors()
+}
+
+type setupSessionResponse struct {
+	SessionID string `json:"sessionId"`
+	SetupURL  string `json:"setupUrl"`
+}
+
+type paymentMethod struct {
+	ID        string    `json:"id"`
+	Brand     string    `json:"brand"`
+	Last4     string    `json:"last4"`
+	ExpMonth  int64     `json:"expMonth"`
+	ExpYear   int64     `json:"expYear"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type paymentMethodsResponse struct {
+	PaymentMethods         []paymentMethod `json:"paymentMethods"`
+	DefaultPaymentMethodID string          `json:"defaultPaymentMethodId"`
+}
+
+func paymentMethodsFromProto(p *paymentpb.ListPaymentMethodsResponse) *paymentMethodsResponse {
+	methods := make([]paymentMethod, len(p.GetPaymentMethods()))
+	for i, m := range p.GetPaymentMethods() {
+		methods[i] = paymentMethod{
+			ID:        m.GetId(),
+			Brand:     m.GetBrand(),
+			Last4:     m.GetLast4(),
+			ExpMonth:  m.GetExpMonth(),
+			ExpYear:   m.GetExpYear(),
+			CreatedAt: m.GetCreatedAt().AsTime(),
+		}
+	}
+
+	return &paymentMethodsResponse{
+		PaymentMethods:         methods,
+		DefaultPaymentMethodID: p.GetDefaultPaymentMethodID(),
+	}
+}
+
+// driverEarningsRequest is read from the query string, from and to are RFC 3339 timestamps
+type driverEarningsRequest struct {
+	UserID   string
+	From     time.Time
+	To       time.Time
+	GroupBy  string
+	Currency string
+}
+
+func parseDriverEarningsRequest(query url.Values) (*driverEarningsRequest, []validation.FieldError) {
+	v := validation.New()
+	req := &driverEarningsRequest{
+		UserID:   query.Get("userID"),
+		GroupBy:  query.Get("groupBy"),
+		Currency: query.Get("currency"),
+	}
+	v.Required("userID", req.UserID)
+
+	for _, param := range []struct {
+		field string
+		dest  *time.Time
+	}{{"from", &req.From}, {"to", &req.To}} {
+		field, dest := param.field, param.dest
+		value := query.Get(field)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			v.AddError(field, "must be an RFC 3339 timestamp")
+			continue
+		}
+		*dest = parsed
+	}
+
+	if req.GroupBy != "" && req.GroupBy != "day" && req.GroupBy != "week" {
+		v.AddError("groupBy", "must be day or week")
+	}
+
+	return req, v.Errors()
+}
+
+func (d *driverEarningsRequest) toProto() *paymentpb.GetDriverEarningsRequest {
+	req := &paymentpb.GetDriverEarningsRequest{
+		DriverID: d.UserID,
+		GroupBy:  d.GroupBy,
+		Currency: d.Currency,
+	}
+	if !d.From.IsZero() {
+		req.From = timestamppb.New(d.From)
+	}
+	if !d.To.IsZero() {
+		req.To = timestamppb.New(d.To)
+	}
+	return req
+}
+
+type earningsTotals struct {
+	Fares            types.Money `json:"fares"`
+	Tips             types.Money `json:"tips"`
+	CancellationFees types.Money `json:"cancellationFees"`
+	Refunds          types.Money `json:"refunds"`
+	Commission       types.Money `json:"commission"`
+	PaidOut          types.Money `json:"paidOut"`
+	Net              types.Money `json:"net"`
+}
+
+type periodEarnings struct {
+	Start  time.Time      `json:"start"`
+	Totals earningsTotals `json:"totals"`
+}
+
+type tripEarnings struct {
+	TripID string         `json:"tripId"`
+	Totals earningsTotals `json:"totals"`
+}
+
+type driverEarningsResponse struct {
+	DriverID string           `json:"driverId"`
+	Currency string           `json:"currency"`
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"`
+	Totals   earningsTotals   `json:"totals"`
+	Periods  []periodEarnings `json:"periods"`
+	Trips    []tripEarnings   `json:"trips"`
+	Balance  types.Money      `json:"balance"`
+}
+
+func earningsTotalsFromProto(t *paymentpb.EarningsTotals) earningsTotals {
+	return earningsTotals{
+		Fares:            types.MoneyFromProto(t.GetFares()),
+		Tips:             types.MoneyFromProto(t.GetTips()),
+		CancellationFees: types.MoneyFromProto(t.GetCancellationFees()),
+		Refunds:          types.MoneyFromProto(t.GetRefunds()),
+		Commission:       types.MoneyFromProto(t.GetCommission()),
+		PaidOut:          types.MoneyFromProto(t.GetPaidOut()),
+		Net:              types.MoneyFromProto(t.GetNet()),
+	}
+}
+
+// driverEarningsFromProto returns timestamps as RFC 3339 strings instead of the protobuf seconds and nanos
+func driverEarningsFromProto(e *paymentpb.GetDriverEarningsResponse) *driverEarningsResponse {
+	periods := make([]periodEarnings, len(e.GetPeriods()))
+	for i, period := range e.GetPeriods() {
+		periods[i] = periodEarnings{
+			Start:  period.GetStart().AsTime(),
+			Totals: earningsTotalsFromProto(period.GetTotals()),
+		}
+	}
+
+	trips := make([]tripEarnings, len(e.GetTrips()))
+	for i, trip := range e.GetTrips() {
+		trips[i] = tripEarnings{
+			TripID: trip.GetTripID(),
+			Totals: earningsTotalsFromProto(trip.GetTotals()),
+		}
+	}
+
+	return &driverEarningsResponse{
+		DriverID: e.GetDriverID(),
+		Currency: e.GetCurrency(),
+		From:     e.GetFrom().AsTime(),
+		To:       e.GetTo().AsTime(),
+		Totals:   earningsTotalsFromProto(e.GetTotals()),
+		Periods:  periods,
+		Trips:    trips,
+		Balance:  types.MoneyFromProto(e.GetBalance()),
+	}
+}
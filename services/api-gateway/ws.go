@@ -3,20 +3,43 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 
 	grpcclients "github.com/tenteedee/mini-uber/services/api-gateway/grpc_clients"
 	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/env"
 	"github.com/tenteedee/mini-uber/shared/messaging"
 	pb "github.com/tenteedee/mini-uber/shared/proto/driver"
+	"github.com/tenteedee/mini-uber/shared/validation"
 )
 
 var (
-	connManager = messaging.NewConnectionManager()
+	connManager   = messaging.NewConnectionManager()
+	wsConnections = newConnectionLimiter(env.GetInt("WS_MAX_CONNECTIONS_PER_USER", 2))
 )
 
+// acquireWSConnection reserves a websocket slot for the user before upgrading,
+// replying with 429 when the user already has too many open connections.
+func acquireWSConnection(w http.ResponseWriter, userID string) bool {
+	if wsConnections.acquire(userID) {
+		return true
+	}
+
+	writeError(w, http.StatusTooManyRequests, contracts.ErrCodeTooManyConnections, "too many open connections for this user")
+	return false
+}
+
 func handleRidersWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging.RabbitMQ) {
+	userId := r.URL.Query().Get("userID")
+	if userId != "" {
+		if !acquireWSConnection(w, userId) {
+			return
+		}
+		defer wsConnections.release(userId)
+	}
+
 	conn, err := connManager.Upgrade(w, r)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
@@ -24,7 +47,6 @@ func handleRidersWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging
 	}
 	defer conn.Close()
 
-	userId := r.URL.Query().Get("userID")
 	if userId == "" {
 		log.Printf("Missing userID in query parameters")
 		conn.Close()
@@ -32,13 +54,15 @@ func handleRidersWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging
 	}
 
 	connManager.Add(userId, conn)
-	defer connManager.Remove(userId)
+	defer connManager.Remove(userId, conn)
 
 	// queue consumers
 	queues := []string{
 		messaging.NotifyDriversNoDriversFoundQueue,
 		messaging.NotifyDriverAssignQueue,
 		messaging.NotifyPaymentSessionCreatedQueue,
+		messaging.NotifyPaymentStatusQueue,
+		messaging.NotifyTripCompletedQueue,
 	}
 
 	for _, qName := range queues {
@@ -48,6 +72,8 @@ func handleRidersWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging
 			log.Printf("failed to start queue consumer for queue %s: %v", qName, err)
 			return
 		}
+		// stop consuming once the websocket is gone, otherwise the consumer is restarted on every reconnection
+		defer consumer.Stop()
 	}
 
 	for {
@@ -61,7 +87,15 @@ func handleRidersWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging
 
 }
 
-func handleDriverWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging.RabbitMQ) {
+func handleDriverWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging.RabbitMQ, driverService *grpcclients.DriverServiceClient) {
+	userId := r.URL.Query().Get("userID")
+	if userId != "" {
+		if !acquireWSConnection(w, userId) {
+			return
+		}
+		defer wsConnections.release(userId)
+	}
+
 	conn, err := connManager.Upgrade(w, r)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
@@ -69,7 +103,6 @@ func handleDriverWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging
 	}
 	defer conn.Close()
 
-	userId := r.URL.Query().Get("userID")
 	if userId == "" {
 		log.Printf("Missing userID in query parameters")
 		conn.Close()
@@ -77,26 +110,22 @@ func handleDriverWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging
 	}
 
 	packageSlug := r.URL.Query().Get("packageSlug")
-	if packageSlug == "" {
-		log.Printf("Missing packageSlug in query parameters")
+	v := validation.New()
+	v.PackageSlug("packageSlug", packageSlug)
+	if !v.Valid() {
+		log.Printf("Invalid packageSlug in query parameters: %+v", v.Errors())
 		conn.Close()
 		return
 	}
 
 	connManager.Add(userId, conn)
 
-	driverService, err := grpcclients.NewDriverServiceClient()
-	if err != nil {
-		log.Printf("Failed to create driver service client: %v", err)
-		return
-	}
-	defer driverService.Close()
-
 	// ensure driver is unregistered when the connection is closed
 	defer func() {
-		connManager.Remove(userId)
+		connManager.Remove(userId, conn)
 
-		_, err := driverService.Client.UnregisterDriver(r.Context(), &pb.RegisterDriverRequest{
+		// the request context may already be done once the client went away, still make sure the driver is removed
+		_, err := driverService.Client.UnregisterDriver(context.Background(), &pb.RegisterDriverRequest{
 			DriverId:    userId,
 			PackageSlug: packageSlug,
 		})
@@ -127,6 +156,7 @@ func handleDriverWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging
 	// queue consumers
 	queues := []string{
 		messaging.DriverCmdTripRequestQueue,
+		messaging.NotifyDriverTipQueue,
 	}
 
 	for _, qName := range queues {
@@ -136,6 +166,8 @@ func handleDriverWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging
 			log.Printf("failed to start queue consumer for queue %s: %v", qName, err)
 			return
 		}
+		// stop consuming once the websocket is gone, otherwise the consumer is restarted on every reconnection
+		defer consumer.Stop()
 	}
 
 	for {
@@ -168,16 +200,30 @@ func handleDriverWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging
 				log.Printf("Error sending update location message: %v", err)
 			}
 			continue
-		case contracts.DriverCmdTripAccept, contracts.DriverCmdTripDecline:
-			if err := rb.PublishMessage(context.Background(), driverMsg.Type, contracts.AmqpMessage{
-				OwnerID: userId,
-				Data:    driverMsg.Data,
-			}); err != nil {
-				log.Printf("Error publishing driver trip response message: %v", err)
-			}
+		case contracts.DriverCmdTripAccept:
+			err = publishDriverCommand(context.Background(), rb, messaging.DriverCmdTripAccept, userId, driverMsg.Data)
+		case contracts.DriverCmdTripDecline:
+			err = publishDriverCommand(context.Background(), rb, messaging.DriverCmdTripDecline, userId, driverMsg.Data)
+		case contracts.DriverCmdTripComplete:
+			err = publishDriverCommand(context.Background(), rb, messaging.DriverCmdTripComplete, userId, driverMsg.Data)
 		default:
 			log.Printf("Unknown driver message type: %s", driverMsg.Type)
+			continue
 		}
 
+		if err != nil {
+			log.Printf("Error publishing driver trip response message: %v", err)
+		}
 	}
 }
+
+// publishDriverCommand checks the data the driver sent against the payload of the command, a
+// malformed command is dropped here rather than dead lettered by the trip service
+func publishDriverCommand[T any](ctx context.Context, rb *messaging.RabbitMQ, event messaging.Event[T], driverID string, data json.RawMessage) error {
+	var payload T
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("%w: %s: %v", messaging.ErrSchemaMismatch, event.RoutingKey(), err)
+	}
+
+	return messaging.Publish(ctx, rb, event, driverID, payload)
+}
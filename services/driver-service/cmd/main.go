@@ -7,14 +7,18 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/tenteedee/mini-uber/services/driver-service/internal/infrastructure/events"
 	"github.com/tenteedee/mini-uber/services/driver-service/internal/infrastructure/grpc"
 	"github.com/tenteedee/mini-uber/services/driver-service/internal/service"
 	"github.com/tenteedee/mini-uber/shared/env"
 	"github.com/tenteedee/mini-uber/shared/messaging"
+	pb "github.com/tenteedee/mini-uber/shared/proto/driver"
 	"github.com/tenteedee/mini-uber/shared/tracing"
 	grpcserver "google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 var GrpcAddr = ":9092"
@@ -52,7 +56,7 @@ func main() {
 	}
 
 	// Initialize RabbitMQ connection
-	rabbitmq, err := messaging.NewRabbitMQ(rabbitmqURI)
+	rabbitmq, err := messaging.NewRabbitMQ(rabbitmqURI, tracerCfg.ServiceName)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -63,8 +67,20 @@ func main() {
 	grpcServer := grpcserver.NewServer(tracing.WithTracingInterceptors()...)
 	grpc.NewGrpcHandler(grpcServer, driverService)
 
-	// initialize queue consumer
-	consumer := events.NewTripEventConsumer(rabbitmq, driverService)
+	// report serving status so gRPC clients only balance over healthy replicas
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	// and not while RabbitMQ is reconnecting, the events of their calls could not be published
+	rabbitmq.OnStateChange(func(state messaging.ConnectionState) {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if state == messaging.StateConnected {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		healthServer.SetServingStatus(pb.DriverService_ServiceDesc.ServiceName, status)
+	})
+
+	// initialize queue consumer, the service has no database so the handled messages are kept in memory
+	consumer := events.NewTripEventConsumer(rabbitmq, driverService, messaging.NewInmemDedupStore())
 	go func() {
 		if err := consumer.Listen(); err != nil {
 			log.Fatalf("failed to listen to the message: %v", err)
@@ -83,5 +99,14 @@ func main() {
 
 	<-ctx.Done()
 	log.Println("Shutting down the server...")
+	// stop routing new calls to this replica before draining the in-flight ones
+	healthServer.Shutdown()
 	grpcServer.GracefulStop()
+
+	// let the consumers finish the messages they received before the connection closes
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer drainCancel()
+	if err := rabbitmq.Shutdown(drainCtx); err != nil {
+		log.Printf("could not drain RabbitMQ consumers: %v", err)
+	}
 }
@@ -2,70 +2,48 @@ package events
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"math/rand"
 
-	"github.com/rabbitmq/amqp091-go"
 	"github.com/tenteedee/mini-uber/services/driver-service/internal/domain"
-	"github.com/tenteedee/mini-uber/shared/contracts"
 	"github.com/tenteedee/mini-uber/shared/messaging"
 )
 
 type TripEventConsumer struct {
 	rabbitmq *messaging.RabbitMQ
 	service  domain.DriverService
+	dedup    messaging.DedupStore
 }
 
-func NewTripEventConsumer(rabbitmq *messaging.RabbitMQ, service domain.DriverService) *TripEventConsumer {
+func NewTripEventConsumer(rabbitmq *messaging.RabbitMQ, service domain.DriverService, dedup messaging.DedupStore) *TripEventConsumer {
 	return &TripEventConsumer{
 		rabbitmq: rabbitmq,
 		service:  service,
+		dedup:    dedup,
 	}
 }
 
 func (c *TripEventConsumer) Listen() error {
+	subscriptions := messaging.NewSubscriptions()
+	messaging.Subscribe(subscriptions, messaging.TripEventCreated, c.handleFindAndNotifyDrivers)
+	messaging.Subscribe(subscriptions, messaging.TripEventDriverNotInterested, c.handleFindAndNotifyDrivers)
+
 	return c.rabbitmq.ConsumeMessages(
 		messaging.FindAvailableDriversQueue,
-		func(ctx context.Context, msg amqp091.Delivery) error {
-			var tripEvent contracts.AmqpMessage
-
-			if err := json.Unmarshal(msg.Body, &tripEvent); err != nil {
-				log.Printf("failed to unmarshal trip event message: %v", err)
-				return err
-			}
-
-			var payload messaging.TripEventData
-			if err := json.Unmarshal(tripEvent.Data, &payload); err != nil {
-				log.Printf("failed to unmarshal trip event data: %v", err)
-				return err
-			}
-
-			log.Printf("driver received message: %+v", payload)
-
-			switch msg.RoutingKey {
-			case contracts.TripEventCreated, contracts.TripEventDriverNotInterested:
-				return c.handleFindAndNotifyDrivers(ctx, payload)
-			}
-
-			log.Printf("unknown trip event: %+v", payload)
-
-			return nil
-		})
+		messaging.DefaultConsumerConfig(),
+		messaging.Deduplicate(c.dedup, messaging.FindAvailableDriversQueue, subscriptions.Handle),
+	)
 }
 
-func (c *TripEventConsumer) handleFindAndNotifyDrivers(ctx context.Context, payload messaging.TripEventData) error {
+func (c *TripEventConsumer) handleFindAndNotifyDrivers(ctx context.Context, msg messaging.Message[messaging.TripEventData]) error {
+	payload := msg.Data
+	log.Printf("driver received message: %+v", payload)
+
 	suitableDrivers := c.service.FindAvailableDrivers(payload.Trip.SelectedFare.PackageSlug)
 	log.Printf("found %v suitable drivers", len(suitableDrivers))
 
 	if len(suitableDrivers) == 0 {
-		if err := c.rabbitmq.PublishMessage(
-			ctx,
-			contracts.TripEventNoDriversFound,
-			contracts.AmqpMessage{
-				OwnerID: payload.Trip.UserID,
-			},
-		); err != nil {
+		if err := messaging.Publish(ctx, c.rabbitmq, messaging.TripEventNoDriversFound, payload.Trip.UserID, messaging.NoData{}); err != nil {
 			log.Printf("failed to publish message to exchange: %v", err)
 			return err
 		}
@@ -78,20 +56,7 @@ func (c *TripEventConsumer) handleFindAndNotifyDrivers(ctx context.Context, payl
 
 	driver := suitableDrivers[randomIndex]
 
-	marshalledEvent, err := json.Marshal(payload)
-	if err != nil {
-		log.Printf("failed to marshal trip event data: %v", err)
-		return err
-	}
-
-	if err := c.rabbitmq.PublishMessage(
-		ctx,
-		contracts.DriverCmdTripRequest,
-		contracts.AmqpMessage{
-			OwnerID: driver,
-			Data:    marshalledEvent,
-		},
-	); err != nil {
+	if err := messaging.Publish(ctx, c.rabbitmq, messaging.DriverCmdTripRequest, driver, payload); err != nil {
 		log.Printf("failed to publish message to exchange: %v", err)
 		return err
 	}
@@ -2,13 +2,16 @@ package grpc
 
 import (
 	"context"
+	"log"
 
 	"github.com/tenteedee/mini-uber/services/driver-service/internal/service"
+	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/grpcerr"
 	pb "github.com/tenteedee/mini-uber/shared/proto/driver"
+	"github.com/tenteedee/mini-uber/shared/validation"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type driverGrpcHandler struct {
@@ -26,9 +29,17 @@ func NewGrpcHandler(s *grpc.Server, service *service.Service) {
 }
 
 func (h *driverGrpcHandler) RegisterDriver(ctx context.Context, req *pb.RegisterDriverRequest) (*pb.RegisterDriverResponse, error) {
+	v := validation.New()
+	v.Required("driverId", req.GetDriverId())
+	v.PackageSlug("packageSlug", req.GetPackageSlug())
+	if !v.Valid() {
+		return nil, grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid register driver request", v.Errors())
+	}
+
 	driver, err := h.service.RegisterDriver(req.GetDriverId(), req.GetPackageSlug())
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to register driver: %v", err)
+		log.Printf("failed to register driver %s: %v", req.GetDriverId(), err)
+		return nil, grpcerr.New(codes.Internal, contracts.ErrCodeDriverRegistrationFailed, "failed to register driver")
 	}
 
 	return &pb.RegisterDriverResponse{
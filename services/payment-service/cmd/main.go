@@ -3,20 +3,37 @@ package main
 import (
 	"context"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
 	"github.com/tenteedee/mini-uber/services/payment-service/internal/infrastructure/events"
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/infrastructure/fake"
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/infrastructure/grpc"
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/infrastructure/jobs"
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/infrastructure/repository"
 	"github.com/tenteedee/mini-uber/services/payment-service/internal/infrastructure/stripe"
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/infrastructure/webhook"
 	"github.com/tenteedee/mini-uber/services/payment-service/internal/service"
 	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	"github.com/tenteedee/mini-uber/shared/db"
 	"github.com/tenteedee/mini-uber/shared/env"
 	"github.com/tenteedee/mini-uber/shared/messaging"
+	pb "github.com/tenteedee/mini-uber/shared/proto/payment"
 	"github.com/tenteedee/mini-uber/shared/tracing"
+	grpcserver "google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-var GrpcAddr = env.GetString("GRPC_ADDR", ":9004")
+var (
+	GrpcAddr = env.GetString("GRPC_ADDR", ":9004")
+	HttpAddr = env.GetString("HTTP_ADDR", ":8084")
+)
 
 func main() {
 	// initialize tracing
@@ -48,34 +65,185 @@ func main() {
 
 	// Stripe config
 	stripeCfg := &types.PaymentConfig{
-		StripeSecretKey: env.GetString("STRIPE_SECRET_KEY", ""),
-		SuccessURL:      env.GetString("STRIPE_SUCCESS_URL", appURL+"?payment=success"),
-		CancelURL:       env.GetString("STRIPE_CANCEL_URL", appURL+"?payment=cancel"),
+		StripeSecretKey:     env.GetString("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret: env.GetString("STRIPE_WEBHOOK_KEY", ""),
+		SuccessURL:          env.GetString("STRIPE_SUCCESS_URL", appURL+"?payment=success"),
+		CancelURL:           env.GetString("STRIPE_CANCEL_URL", appURL+"?payment=cancel"),
+		SetupSuccessURL:     env.GetString("STRIPE_SETUP_SUCCESS_URL", appURL+"?payment_method=saved"),
+		SetupCancelURL:      env.GetString("STRIPE_SETUP_CANCEL_URL", appURL+"?payment_method=cancel"),
 	}
 
-	if stripeCfg.StripeSecretKey == "" {
-		log.Fatalf("STRIPE_SECRET_KEY is not set")
+	// HTTP server for webhooks, and the checkout page of the fake processor
+	mux := http.NewServeMux()
+
+	var paymentProcessor domain.PaymentProcessor
+	switch processor := env.GetString("PAYMENT_PROCESSOR", "stripe"); processor {
+	case "stripe":
+		if stripeCfg.StripeSecretKey == "" {
+			log.Fatalf("STRIPE_SECRET_KEY is not set")
+			return
+		}
+
+		if stripeCfg.StripeWebhookSecret == "" {
+			log.Printf("STRIPE_WEBHOOK_KEY is not set, Stripe webhooks will be rejected until it is configured")
+		}
+
+		paymentProcessor = stripe.NewStripeClient(stripeCfg)
+	case "fake":
+		// simulates checkout locally, no Stripe account or network needed
+		fakeProcessor := fake.NewProcessor(fake.Config{
+			CheckoutBaseURL: env.GetString("FAKE_PAYMENT_CHECKOUT_URL", "http://localhost"+HttpAddr),
+			WebhookURL:      env.GetString("FAKE_PAYMENT_WEBHOOK_URL", "http://localhost"+HttpAddr+"/webhook/fake"),
+			WebhookSecret:   env.GetString("FAKE_PAYMENT_WEBHOOK_SECRET", "fake_whsec"),
+			Outcome:         fake.Outcome(env.GetString("FAKE_PAYMENT_OUTCOME", string(fake.OutcomeManual))),
+			Delay:           time.Duration(env.GetInt("FAKE_PAYMENT_DELAY_MS", 2000)) * time.Millisecond,
+			SuccessURL:      stripeCfg.SuccessURL,
+			CancelURL:       stripeCfg.CancelURL,
+			ChargeOutcome:   types.ChargeStatus(env.GetString("FAKE_PAYMENT_CHARGE_OUTCOME", string(types.ChargeStatusSucceeded))),
+			SetupSuccessURL: stripeCfg.SetupSuccessURL,
+			SetupCancelURL:  stripeCfg.SetupCancelURL,
+		})
+		fakeProcessor.RegisterRoutes(mux)
+
+		log.Println("Using the fake payment processor, payments are simulated")
+		paymentProcessor = fakeProcessor
+	default:
+		log.Fatalf("unknown PAYMENT_PROCESSOR %q, expected stripe or fake", processor)
 		return
 	}
 
-	// Stripe processor
-	paymentProcessor := stripe.NewStripeClient(stripeCfg)
+	// Fares are split between the platform and the drivers by car package
+	commissionRates, err := types.ParseCommissionRates(env.GetString("COMMISSION_RATES", ""))
+	if err != nil {
+		log.Fatalf("invalid COMMISSION_RATES: %v", err)
+	}
+	defaultCommission := float64(env.GetInt("COMMISSION_PERCENT", 20))
+	if !types.ValidCommissionPercent(defaultCommission) {
+		log.Fatalf("invalid COMMISSION_PERCENT %v, expected a percentage between 0 and 100", defaultCommission)
+	}
+	earningsCfg := &types.EarningsConfig{
+		DefaultCommissionPercent: defaultCommission,
+		CommissionPercent:        commissionRates,
+	}
 
-	paymentService := service.NewPaymentService(paymentProcessor)
+	// Payment, customer and ledger repositories, and the handled messages, they are only kept in memory
+	// when no MongoDB is configured
+	var paymentRepo domain.PaymentRepository
+	var customerRepo domain.CustomerRepository
+	var ledgerRepo domain.LedgerRepository
+	var dedupStore messaging.DedupStore
+	mongoCfg := db.NewMongoDefaultConfig()
+	if mongoCfg.URI != "" {
+		mongoClient, err := db.NewMongoClient(ctx, mongoCfg)
+		if err != nil {
+			log.Fatalf("Failed to initialize MongoDB, err: %v", err)
+		}
+		defer mongoClient.Disconnect(ctx)
+
+		paymentRepo = repository.NewMongoRepository(db.GetDatabase(mongoClient, mongoCfg))
+		customerRepo = repository.NewMongoCustomerRepository(db.GetDatabase(mongoClient, mongoCfg))
+		ledgerRepo = repository.NewMongoLedgerRepository(db.GetDatabase(mongoClient, mongoCfg))
+		dedupStore, err = messaging.NewMongoDedupStore(ctx, db.GetDatabase(mongoClient, mongoCfg))
+		if err != nil {
+			log.Fatalf("Failed to initialize the message dedup store, err: %v", err)
+		}
+	} else {
+		log.Println("MONGODB_URI is not set, payments are stored in memory")
+		paymentRepo = repository.NewInmemRepository()
+		customerRepo = repository.NewInmemCustomerRepository()
+		ledgerRepo = repository.NewInmemLedgerRepository()
+		dedupStore = messaging.NewInmemDedupStore()
+	}
 
 	// RabbitMQ connection
-	rabbitmq, err := messaging.NewRabbitMQ(rabbitMqURI)
+	rabbitmq, err := messaging.NewRabbitMQ(rabbitMqURI, tracerCfg.ServiceName)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer rabbitmq.Close()
 	log.Println("starting RabbitMQ connection on payment service")
 
+	publisher := events.NewPaymentEventPublisher(rabbitmq)
+	earningsService := service.NewEarningsService(ledgerRepo, earningsCfg)
+	paymentService := service.NewPaymentService(paymentProcessor, paymentRepo, customerRepo, publisher, earningsService)
+
 	// Trip consumer
-	tripConsumer := events.NewTripConsumer(rabbitmq, paymentService)
+	tripConsumer := events.NewTripConsumer(rabbitmq, paymentService, dedupStore)
 	go tripConsumer.Listen()
 
+	// Settle payments whose webhooks were missed
+	reconciler := jobs.NewReconciler(paymentService,
+		time.Duration(env.GetInt("RECONCILE_INTERVAL_SECONDS", 60))*time.Second,
+		time.Duration(env.GetInt("RECONCILE_MIN_AGE_SECONDS", 300))*time.Second,
+	)
+	go reconciler.Run(ctx)
+
+	// Pay drivers out what they earned
+	payouts := jobs.NewPayouts(earningsService,
+		time.Duration(env.GetInt("PAYOUT_INTERVAL_HOURS", 24))*time.Hour,
+	)
+	go payouts.Run(ctx)
+
+	// Webhook endpoints
+	webhook.NewHandler(paymentService).RegisterRoutes(mux)
+
+	server := &http.Server{
+		Addr:    HttpAddr,
+		Handler: mux,
+	}
+
+	go func() {
+		log.Printf("Webhook HTTP server listening on %s", HttpAddr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("failed to serve webhook HTTP server: %v", err)
+			cancel()
+		}
+	}()
+
+	// gRPC server
+	listener, err := net.Listen("tcp", GrpcAddr)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+
+	grpcServer := grpcserver.NewServer()
+	grpc.NewgRPCHandler(grpcServer, paymentService, earningsService)
+
+	// report serving status so gRPC clients only balance over healthy replicas
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	// and not while RabbitMQ is reconnecting, the events of their calls could not be published
+	rabbitmq.OnStateChange(func(state messaging.ConnectionState) {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if state == messaging.StateConnected {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		healthServer.SetServingStatus(pb.PaymentService_ServiceDesc.ServiceName, status)
+	})
+
+	go func() {
+		log.Printf("starting Payment gRPC server on %s", listener.Addr().String())
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Printf("failed to serve gRPC server: %v", err)
+			cancel()
+		}
+	}()
+
 	// Wait for shutdown signal
 	<-ctx.Done()
 	log.Println("Shutting down payment service...")
+
+	healthServer.Shutdown()
+	grpcServer.GracefulStop()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("could not shutdown webhook HTTP server: %v", err)
+	}
+
+	// let the consumers finish the messages they received before the connection closes
+	if err := rabbitmq.Shutdown(shutdownCtx); err != nil {
+		log.Printf("could not drain RabbitMQ consumers: %v", err)
+	}
 }
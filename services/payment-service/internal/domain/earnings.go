@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	pb "github.com/tenteedee/mini-uber/shared/proto/payment"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// ErrEntryExists is returned when a journal entry with the same id was already posted
+	ErrEntryExists   = errors.New("journal entry already posted")
+	ErrEntryNotFound = errors.New("journal entry not found")
+)
+
+// EarningsService keeps the double-entry ledger of what drivers earn and are paid out
+type EarningsService interface {
+	// RecordPayment splits a successful payment between the platform and the driver
+	RecordPayment(ctx context.Context, payment *types.Payment) error
+	// RecordRefund takes the refunded amount back from the driver and the platform, in the same proportion as the payment was split
+	RecordRefund(ctx context.Context, refund *types.Refund, payment *types.Payment) error
+	// GetDriverEarnings sums the driver's earnings between from and to, grouped by period and trip.
+	// An empty currency picks the currency of the driver's latest entry.
+	GetDriverEarnings(ctx context.Context, driverID string, from, to time.Time, period types.EarningsPeriod, currency string) (*types.DriverEarnings, error)
+	// CreatePayouts pays out every positive driver balance in a batch, a batch is only ever created once
+	CreatePayouts(ctx context.Context, batchID string) ([]*types.Payout, error)
+}
+
+type LedgerRepository interface {
+	// PostEntry stores a balanced entry, it returns ErrEntryExists when it was already posted
+	PostEntry(ctx context.Context, entry *types.JournalEntry) error
+	GetEntry(ctx context.Context, id string) (*types.JournalEntry, error)
+	// ListDriverEntries returns the entries of the driver created in [from, to), oldest first
+	ListDriverEntries(ctx context.Context, driverID string, from, to time.Time) ([]*types.JournalEntry, error)
+	// GetLatestDriverEntry returns the driver's most recent entry, or ErrEntryNotFound
+	GetLatestDriverEntry(ctx context.Context, driverID string) (*types.JournalEntry, error)
+	GetAccountBalance(ctx context.Context, account string, currency string) (int64, error)
+	// ListDriverBalances returns the balance of every driver account, per currency
+	ListDriverBalances(ctx context.Context) ([]*types.DriverBalance, error)
+	CreatePayout(ctx context.Context, payout *types.Payout) error
+}
+
+func ToEarningsTotalsProto(t *types.EarningsTotals) *pb.EarningsTotals {
+	return &pb.EarningsTotals{
+		Fares:            t.Fares.ToProto(),
+		Tips:             t.Tips.ToProto(),
+		CancellationFees: t.CancellationFees.ToProto(),
+		Refunds:          t.Refunds.ToProto(),
+		Commission:       t.Commission.ToProto(),
+		PaidOut:          t.PaidOut.ToProto(),
+		Net:              t.Net().ToProto(),
+	}
+}
+
+func ToDriverEarningsProto(e *types.DriverEarnings) *pb.GetDriverEarningsResponse {
+	periods := make([]*pb.PeriodEarnings, len(e.Periods))
+	for i, period := range e.Periods {
+		periods[i] = &pb.PeriodEarnings{
+			Start:  timestamppb.New(period.Start),
+			Totals: ToEarningsTotalsProto(period.Totals),
+		}
+	}
+
+	trips := make([]*pb.TripEarnings, len(e.Trips))
+	for i, trip := range e.Trips {
+		trips[i] = &pb.TripEarnings{
+			TripID: trip.TripID,
+			Totals: ToEarningsTotalsProto(trip.Totals),
+		}
+	}
+
+	return &pb.GetDriverEarningsResponse{
+		DriverID: e.DriverID,
+		Currency: e.Currency,
+		From:     timestamppb.New(e.From),
+		To:       timestamppb.New(e.To),
+		Totals:   ToEarningsTotalsProto(e.Totals),
+		Periods:  periods,
+		Trips:    trips,
+		Balance:  e.Balance.ToProto(),
+	}
+}
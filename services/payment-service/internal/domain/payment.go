@@ -2,15 +2,178 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	pb "github.com/tenteedee/mini-uber/shared/proto/payment"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrWebhookNotConfigured = errors.New("webhook secret is not configured")
+	ErrInvalidWebhook       = errors.New("invalid webhook payload or signature")
+	ErrPaymentNotFound      = errors.New("payment not found")
+	// ErrPaymentStatusConflict is returned when the payment is no longer in the status an update expected
+	ErrPaymentStatusConflict = errors.New("payment status changed concurrently")
+	ErrPaymentNotRefundable  = errors.New("only successful payments can be refunded")
+	ErrInvalidRefundAmount   = errors.New("refund amount exceeds what is left to refund")
+	ErrCustomerNotFound      = errors.New("customer not found")
+	ErrCustomerExists        = errors.New("customer already exists")
+	ErrPaymentMethodNotFound = errors.New("payment method not found")
 )
 
 type Service interface {
-	CreatePaymentSession(ctx context.Context, tripID, userID, driverID string, amount int64, currency string) (*types.PaymentIntent, error)
+	CreatePaymentSession(ctx context.Context, tripID, userID, driverID, packageSlug string, amount sharedTypes.Money) (*types.PaymentIntent, error)
+	// CreateTipSession charges the rider's tip for the driver, separately from the fare of the trip
+	CreateTipSession(ctx context.Context, tripID, tipID, userID, driverID string, amount sharedTypes.Money) (*types.PaymentIntent, error)
+	// HandleWebhook verifies a payment processor webhook and applies the payment outcome it reports, if any
+	HandleWebhook(ctx context.Context, payload []byte, signature string) error
+	GetPayment(ctx context.Context, paymentID string) (*types.Payment, error)
+	ListPaymentsForTrip(ctx context.Context, tripID string) ([]*types.Payment, error)
+	// ReconcilePendingPayments asks the processor about payments pending for longer than olderThan
+	// and applies the outcome of those whose webhooks were missed. It returns how many were settled.
+	ReconcilePendingPayments(ctx context.Context, olderThan time.Duration) (int, error)
+	// RefundPayment refunds amount of a successful payment, or everything not refunded yet when amount is zero
+	RefundPayment(ctx context.Context, paymentID string, amount sharedTypes.Money, reason string) (*types.Refund, *types.Payment, error)
+	// ChargeTrip charges the fare of a completed trip to the saved payment method it was scheduled on. When that charge
+	// fails or needs the rider to authenticate, a checkout session is opened instead and returned with its fallback reason.
+	// It returns nil when the trip has no scheduled charge.
+	ChargeTrip(ctx context.Context, tripID string) (*types.PaymentIntent, error)
+
+	// CreateSetupSession opens a hosted page on which the rider saves a payment method
+	CreateSetupSession(ctx context.Context, userID string) (*types.CheckoutSession, error)
+	// GetCustomer returns the rider's saved payment methods, a rider who never saved one has none
+	GetCustomer(ctx context.Context, userID string) (*types.Customer, error)
+	SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID string) (*types.Customer, error)
+	RemovePaymentMethod(ctx context.Context, userID, paymentMethodID string) (*types.Customer, error)
+}
+
+// EventPublisher publishes the outcome of payments to the rest of the system
+type EventPublisher interface {
+	PublishPaymentEvent(ctx context.Context, event *types.PaymentEvent) error
+	PublishRefundEvent(ctx context.Context, refund *types.Refund, payment *types.Payment) error
+	// PublishTipEvent reports the outcome of a tip payment, instead of PublishPaymentEvent
+	PublishTipEvent(ctx context.Context, payment *types.Payment, event *types.PaymentEvent) error
+}
+
+type PaymentRepository interface {
+	CreatePayment(ctx context.Context, payment *types.Payment) error
+	GetPaymentByID(ctx context.Context, id string) (*types.Payment, error)
+	GetPaymentBySessionID(ctx context.Context, sessionID string) (*types.Payment, error)
+	ListPaymentsByTripID(ctx context.Context, tripID string) ([]*types.Payment, error)
+	// ListPaymentsByStatus returns up to limit payments in the status created before the given time, oldest first.
+	// Payments scheduled on a saved payment method have no session yet and are left out.
+	ListPaymentsByStatus(ctx context.Context, status types.PaymentStatus, createdBefore time.Time, limit int) ([]*types.Payment, error)
+	// UpdatePaymentStatus moves the payment from the expected status to the next one,
+	// it returns ErrPaymentStatusConflict when the payment is not in the expected status anymore.
+	UpdatePaymentStatus(ctx context.Context, id string, expected, next types.PaymentStatus, reason string) error
+	// AddRefundedAmount adds amount (negative to release it) to the refunded total of a successful payment,
+	// it returns ErrInvalidRefundAmount when the total would exceed the payment amount.
+	AddRefundedAmount(ctx context.Context, id string, amount sharedTypes.Money) (*types.Payment, error)
+	CreateRefund(ctx context.Context, refund *types.Refund) error
+	// SetPaymentSession records the checkout session, or the charge, the payment is collected with
+	SetPaymentSession(ctx context.Context, id, sessionID, checkoutURL string) error
+}
+
+type CustomerRepository interface {
+	// GetCustomer returns ErrCustomerNotFound for riders who never saved a payment method
+	GetCustomer(ctx context.Context, userID string) (*types.Customer, error)
+	// CreateCustomer returns ErrCustomerExists when the rider already has a customer
+	CreateCustomer(ctx context.Context, customer *types.Customer) error
+	// AddPaymentMethod saves the method unless it already is, the first method saved becomes the default
+	AddPaymentMethod(ctx context.Context, userID string, method *types.PaymentMethod) (*types.Customer, error)
+	SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID string) (*types.Customer, error)
+	// RemovePaymentMethod forgets the method, removing the default makes the most recently saved method the default
+	RemovePaymentMethod(ctx context.Context, userID, paymentMethodID string) (*types.Customer, error)
 }
 
 type PaymentProcessor interface {
-	CreatePaymentSession(ctx context.Context, amount int64, currency string, metadata map[string]string) (string, error)
-	// GetSessionStatus(ctx context.Context, sessionID string) (types.PaymentStatus, error)
+	CreatePaymentSession(ctx context.Context, amount sharedTypes.Money, metadata map[string]string) (*types.CheckoutSession, error)
+	// GetSessionStatus returns the status of the payment as currently known by the processor,
+	// the session is either a checkout session or a charge of a saved payment method
+	GetSessionStatus(ctx context.Context, sessionID string) (types.PaymentStatus, error)
+	// Refund gives amount back on the payment of the session and returns the processor's refund id
+	Refund(ctx context.Context, sessionID string, amount sharedTypes.Money, reason string) (string, error)
+
+	// CreateCustomer creates the rider's account on the processor, payment methods are saved on it
+	CreateCustomer(ctx context.Context, userID string) (string, error)
+	// CreateSetupSession opens a hosted page on which the customer saves a payment method for later charges
+	CreateSetupSession(ctx context.Context, customerID string, metadata map[string]string) (*types.CheckoutSession, error)
+	// ChargePaymentMethod charges a saved payment method without the rider being present. A declined charge, or one
+	// needing the rider to authenticate, is reported in the Charge. Errors are left to failing to reach the processor,
+	// charging again with the same idempotency key then never charges twice.
+	ChargePaymentMethod(ctx context.Context, customerID, paymentMethodID string, amount sharedTypes.Money, metadata map[string]string, idempotencyKey string) (*types.Charge, error)
+	DetachPaymentMethod(ctx context.Context, paymentMethodID string) error
+
+	// ParseWebhookEvent verifies the webhook signature and translates the processor event.
+	// It returns a nil event for notifications that don't change the outcome of a payment.
+	ParseWebhookEvent(payload []byte, signature string) (*types.PaymentEvent, error)
+}
+
+func ToPaymentProto(p *types.Payment) *pb.Payment {
+	kind := p.Kind
+	if kind == "" {
+		kind = types.PaymentKindFare
+	}
+
+	return &pb.Payment{
+		Id:              p.ID,
+		Kind:            string(kind),
+		TripID:          p.TripID,
+		UserID:          p.UserID,
+		DriverID:        p.DriverID,
+		PackageSlug:     p.PackageSlug,
+		Amount:          p.Amount.ToProto(),
+		Status:          string(p.Status),
+		StripeSessionID: p.StripeSessionID,
+		FailureReason:   p.FailureReason,
+		CreatedAt:       timestamppb.New(p.CreatedAt),
+		UpdatedAt:       timestamppb.New(p.UpdatedAt),
+		RefundedAmount:  p.RefundedAmount.ToProto(),
+	}
+}
+
+func ToRefundProto(r *types.Refund) *pb.Refund {
+	return &pb.Refund{
+		Id:                r.ID,
+		PaymentID:         r.PaymentID,
+		TripID:            r.TripID,
+		Amount:            r.Amount.ToProto(),
+		Reason:            r.Reason,
+		ProcessorRefundID: r.ProcessorRefundID,
+		CreatedAt:         timestamppb.New(r.CreatedAt),
+	}
+}
+
+func ToPaymentMethodsProto(c *types.Customer) *pb.ListPaymentMethodsResponse {
+	methods := make([]*pb.PaymentMethod, len(c.PaymentMethods))
+
+	for i, m := range c.PaymentMethods {
+		methods[i] = &pb.PaymentMethod{
+			Id:        m.ID,
+			Brand:     m.Brand,
+			Last4:     m.Last4,
+			ExpMonth:  m.ExpMonth,
+			ExpYear:   m.ExpYear,
+			CreatedAt: timestamppb.New(m.CreatedAt),
+		}
+	}
+
+	return &pb.ListPaymentMethodsResponse{
+		PaymentMethods:         methods,
+		DefaultPaymentMethodID: c.DefaultPaymentMethodID,
+	}
+}
+
+func ToPaymentsProto(payments []*types.Payment) []*pb.Payment {
+	protoPayments := make([]*pb.Payment, len(payments))
+
+	for i, p := range payments {
+		protoPayments[i] = ToPaymentProto(p)
+	}
+
+	return protoPayments
 }
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	"github.com/tenteedee/mini-uber/shared/messaging"
+)
+
+type PaymentEventPublisher struct {
+	rabbitmq *messaging.RabbitMQ
+}
+
+func NewPaymentEventPublisher(rabbitmq *messaging.RabbitMQ) *PaymentEventPublisher {
+	return &PaymentEventPublisher{
+		rabbitmq: rabbitmq,
+	}
+}
+
+// PublishPaymentEvent publishes the payment.event.* message matching the outcome of a payment
+func (p *PaymentEventPublisher) PublishPaymentEvent(ctx context.Context, event *types.PaymentEvent) error {
+	var paymentEvent messaging.Event[messaging.PaymentStatusUpdateData]
+	switch event.Type {
+	case types.PaymentEventSucceeded:
+		paymentEvent = messaging.PaymentEventSuccess
+	case types.PaymentEventFailed:
+		paymentEvent = messaging.PaymentEventFailed
+	case types.PaymentEventCancelled:
+		paymentEvent = messaging.PaymentEventCancelled
+	default:
+		return fmt.Errorf("unknown payment event type: %s", event.Type)
+	}
+
+	payload := messaging.PaymentStatusUpdateData{
+		TripID:    event.Metadata["trip_id"],
+		UserID:    event.Metadata["user_id"],
+		DriverID:  event.Metadata["driver_id"],
+		SessionID: event.SessionID,
+		Reason:    event.Reason,
+	}
+
+	return messaging.Publish(ctx, p.rabbitmq, paymentEvent, payload.UserID, payload)
+}
+
+// PublishRefundEvent publishes payment.event.refunded once money was given back on a payment
+func (p *PaymentEventPublisher) PublishRefundEvent(ctx context.Context, refund *types.Refund, payment *types.Payment) error {
+	payload := messaging.PaymentRefundedData{
+		TripID:         payment.TripID,
+		UserID:         payment.UserID,
+		PaymentID:      payment.ID,
+		RefundID:       refund.ID,
+		Amount:         refund.Amount,
+		Reason:         refund.Reason,
+		FullyRefunded:  payment.RefundedAmount.Amount >= payment.Amount.Amount,
+		RefundedAmount: payment.RefundedAmount,
+	}
+
+	return messaging.Publish(ctx, p.rabbitmq, messaging.PaymentEventRefunded, payload.UserID, payload)
+}
+
+// PublishTipEvent lets the driver know a tip was received (payment.event.tip_received),
+// or the rider that it could not be charged (payment.event.tip_failed)
+func (p *PaymentEventPublisher) PublishTipEvent(ctx context.Context, payment *types.Payment, event *types.PaymentEvent) error {
+	payload := messaging.PaymentTipData{
+		TripID:   payment.TripID,
+		TipID:    payment.ID,
+		UserID:   payment.UserID,
+		DriverID: payment.DriverID,
+		Amount:   payment.Amount,
+	}
+
+	tipEvent := messaging.PaymentEventTipReceived
+	ownerID := payment.DriverID
+	if event.Type != types.PaymentEventSucceeded {
+		tipEvent = messaging.PaymentEventTipFailed
+		ownerID = payment.UserID
+		payload.Reason = event.Reason
+	}
+
+	return messaging.Publish(ctx, p.rabbitmq, tipEvent, ownerID, payload)
+}
@@ -2,55 +2,42 @@ package events
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 
 	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
-	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
 	"github.com/tenteedee/mini-uber/shared/messaging"
-
-	"github.com/rabbitmq/amqp091-go"
 )
 
 type TripConsumer struct {
 	rabbitmq *messaging.RabbitMQ
 	service  domain.Service
+	dedup    messaging.DedupStore
 }
 
-func NewTripConsumer(rabbitmq *messaging.RabbitMQ, service domain.Service) *TripConsumer {
+func NewTripConsumer(rabbitmq *messaging.RabbitMQ, service domain.Service, dedup messaging.DedupStore) *TripConsumer {
 	return &TripConsumer{
 		rabbitmq: rabbitmq,
 		service:  service,
+		dedup:    dedup,
 	}
 }
 
 func (c *TripConsumer) Listen() error {
-	return c.rabbitmq.ConsumeMessages(messaging.PaymentTripResponseQueue, func(ctx context.Context, msg amqp091.Delivery) error {
-		var message contracts.AmqpMessage
-		if err := json.Unmarshal(msg.Body, &message); err != nil {
-			log.Printf("Failed to unmarshal message: %v", err)
-			return err
-		}
-
-		var payload messaging.PaymentTripResponseData
-		if err := json.Unmarshal(message.Data, &payload); err != nil {
-			log.Printf("Failed to unmarshal payload: %v", err)
-			return err
-		}
-
-		switch msg.RoutingKey {
-		case contracts.PaymentCmdCreateSession:
-			if err := c.handleTripAccepted(ctx, payload); err != nil {
-				log.Printf("Failed to handle trip accepted: %v", err)
-				return err
-			}
-		}
-
-		return nil
-	})
+	subscriptions := messaging.NewSubscriptions()
+	messaging.Subscribe(subscriptions, messaging.PaymentCmdCreateSession, c.handleTripAccepted)
+	messaging.Subscribe(subscriptions, messaging.PaymentCmdCreateTip, c.handleTipRequested)
+	messaging.Subscribe(subscriptions, messaging.TripEventCompleted, c.handleTripCompleted)
+
+	return c.rabbitmq.ConsumeMessages(
+		messaging.PaymentTripResponseQueue,
+		messaging.DefaultConsumerConfig(),
+		messaging.Deduplicate(c.dedup, messaging.PaymentTripResponseQueue, subscriptions.Handle),
+	)
 }
 
-func (c *TripConsumer) handleTripAccepted(ctx context.Context, payload messaging.PaymentTripResponseData) error {
+func (c *TripConsumer) handleTripAccepted(ctx context.Context, msg messaging.Message[messaging.PaymentTripResponseData]) error {
+	payload := msg.Data
 	log.Printf("Handling trip accepted by driver: %s", payload.TripID)
 
 	paymentSession, err := c.service.CreatePaymentSession(
@@ -58,40 +45,118 @@ func (c *TripConsumer) handleTripAccepted(ctx context.Context, payload messaging
 		payload.TripID,
 		payload.UserID,
 		payload.DriverID,
-		int64(payload.Amount),
-		payload.Currency,
+		payload.PackageSlug,
+		payload.Amount,
 	)
 	if err != nil {
 		log.Printf("Failed to create payment session: %v", err)
 		return err
 	}
 
+	if paymentSession.PaymentMethod != nil {
+		return c.publishChargeScheduled(ctx, paymentSession)
+	}
+
 	log.Printf("Payment session created: %s", paymentSession.StripeSessionID)
 
 	// publish payment session created event
 	paymentPayload := messaging.PaymentEventSessionCreatedData{
-		TripID:    payload.TripID,
-		SessionID: paymentSession.StripeSessionID,
-		Amount:    float64(paymentSession.Amount) / 100.0, // convert from cents to dollars
-		Currency:  paymentSession.Currency,
+		TripID:      payload.TripID,
+		SessionID:   paymentSession.StripeSessionID,
+		CheckoutURL: paymentSession.CheckoutURL,
+		Amount:      paymentSession.Amount,
 	}
 
-	payloadBytes, err := json.Marshal(paymentPayload)
+	if err := messaging.Publish(ctx, c.rabbitmq, messaging.PaymentEventSessionCreated, payload.UserID, paymentPayload); err != nil {
+		log.Printf("Failed to publish payment session created event: %v", err)
+		return err
+	}
+
+	log.Printf("Published payment session created event for trip: %s", payload.TripID)
+	return nil
+}
+
+func (c *TripConsumer) handleTipRequested(ctx context.Context, msg messaging.Message[messaging.PaymentTipData]) error {
+	payload := msg.Data
+
+	log.Printf("Handling tip %s of %s on trip %s", payload.TipID, payload.Amount, payload.TripID)
+
+	paymentSession, err := c.service.CreateTipSession(
+		ctx,
+		payload.TripID,
+		payload.TipID,
+		payload.UserID,
+		payload.DriverID,
+		payload.Amount,
+	)
 	if err != nil {
-		log.Printf("Failed to marshal payment session payload: %v", err)
+		log.Printf("Failed to create tip payment session: %v", err)
 		return err
 	}
 
-	if err := c.rabbitmq.PublishMessage(ctx, contracts.PaymentEventSessionCreated,
-		contracts.AmqpMessage{
-			OwnerID: payload.UserID,
-			Data:    payloadBytes,
-		},
-	); err != nil {
+	// the rider pays the tip on its own checkout session
+	paymentPayload := messaging.PaymentEventSessionCreatedData{
+		TripID:      payload.TripID,
+		SessionID:   paymentSession.StripeSessionID,
+		CheckoutURL: paymentSession.CheckoutURL,
+		Amount:      paymentSession.Amount,
+		TipID:       payload.TipID,
+	}
+
+	if err := messaging.Publish(ctx, c.rabbitmq, messaging.PaymentEventSessionCreated, payload.UserID, paymentPayload); err != nil {
+		log.Printf("Failed to publish tip payment session created event: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// handleTripCompleted charges the fare to the rider's saved payment method, or asks them to check out
+// when the charge doesn't go through
+func (c *TripConsumer) handleTripCompleted(ctx context.Context, msg messaging.Message[messaging.TripEventData]) error {
+	tripID := msg.Data.Trip.GetId()
+	intent, err := c.service.ChargeTrip(ctx, tripID)
+	if err != nil {
+		log.Printf("Failed to charge trip %s: %v", tripID, err)
+		return err
+	}
+
+	// nothing was scheduled, or the charge went through and its success is already published
+	if intent == nil || intent.CheckoutURL == "" {
+		return nil
+	}
+
+	if err := messaging.Publish(ctx, c.rabbitmq, messaging.PaymentEventSessionCreated, intent.UserID, messaging.PaymentEventSessionCreatedData{
+		TripID:      tripID,
+		SessionID:   intent.StripeSessionID,
+		CheckoutURL: intent.CheckoutURL,
+		Amount:      intent.Amount,
+		Reason:      intent.FallbackReason,
+	}); err != nil {
 		log.Printf("Failed to publish payment session created event: %v", err)
 		return err
 	}
 
-	log.Printf("Published payment session created event for trip: %s", payload.TripID)
+	log.Printf("Trip %s falls back to checkout session %s: %s", tripID, intent.StripeSessionID, intent.FallbackReason)
+	return nil
+}
+
+func (c *TripConsumer) publishChargeScheduled(ctx context.Context, intent *types.PaymentIntent) error {
+	if err := messaging.Publish(ctx, c.rabbitmq, messaging.PaymentEventChargeScheduled, intent.UserID, messaging.PaymentChargeScheduledData{
+		TripID:   intent.TripID,
+		UserID:   intent.UserID,
+		DriverID: intent.DriverID,
+		Amount:   intent.Amount,
+		PaymentMethod: messaging.PaymentMethodSummary{
+			ID:    intent.PaymentMethod.ID,
+			Brand: intent.PaymentMethod.Brand,
+			Last4: intent.PaymentMethod.Last4,
+		},
+	}); err != nil {
+		log.Printf("Failed to publish charge scheduled event: %v", err)
+		return err
+	}
+
+	log.Printf("Published charge scheduled event for trip: %s", intent.TripID)
 	return nil
 }
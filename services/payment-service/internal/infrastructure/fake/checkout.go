@@ -0,0 +1,138 @@
+package fake
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+)
+
+var checkoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Fake checkout</title></head>
+<body style="font-family: sans-serif; max-width: 420px; margin: 40px auto;">
+  <h1>Fake checkout</h1>
+  <p>Ride payment for trip <code>{{.TripID}}</code></p>
+  <p><strong>{{.Amount}}</strong></p>
+  {{if .Completed}}
+  <p>This session has already been completed.</p>
+  {{else}}
+  <form method="POST">
+    <button name="outcome" value="success">Pay</button>
+    <button name="outcome" value="failure">Decline card</button>
+    <button name="outcome" value="cancel">Cancel</button>
+  </form>
+  {{end}}
+</body>
+</html>
+`))
+
+var setupPage = template.Must(template.New("setup").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Fake card setup</title></head>
+<body style="font-family: sans-serif; max-width: 420px; margin: 40px auto;">
+  <h1>Save a card</h1>
+  <p>The test card Visa ending in 4242 will be charged at the end of your trips.</p>
+  {{if .Completed}}
+  <p>This card has already been saved.</p>
+  {{else}}
+  <form method="POST">
+    <button name="outcome" value="success">Save card</button>
+    <button name="outcome" value="cancel">Cancel</button>
+  </form>
+  {{end}}
+</body>
+</html>
+`))
+
+// RegisterRoutes adds the hosted checkout and card setup pages, the counterparts of Stripe Checkout
+func (p *Processor) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /fake/checkout/{sessionID}", p.handleCheckoutPage)
+	mux.HandleFunc("POST /fake/checkout/{sessionID}", p.handleCheckoutSubmit)
+	mux.HandleFunc("GET /fake/setup/{setupID}", p.handleSetupPage)
+	mux.HandleFunc("POST /fake/setup/{setupID}", p.handleSetupSubmit)
+}
+
+func (p *Processor) handleCheckoutPage(w http.ResponseWriter, r *http.Request) {
+	p.mutex.Lock()
+	s, ok := p.sessions[r.PathValue("sessionID")]
+	var data struct {
+		TripID    string
+		Amount    sharedTypes.Money
+		Completed bool
+	}
+	if ok {
+		data.TripID = s.Metadata["trip_id"]
+		data.Amount = s.Amount
+		data.Completed = s.Status != types.PaymentStatusPending
+	}
+	p.mutex.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := checkoutPage.Execute(w, data); err != nil {
+		log.Printf("Failed to render fake checkout page: %v", err)
+	}
+}
+
+func (p *Processor) handleCheckoutSubmit(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("sessionID")
+	outcome := Outcome(r.FormValue("outcome"))
+
+	if err := p.complete(r.Context(), sessionID, outcome); err != nil {
+		log.Printf("Failed to complete fake payment session %s: %v", sessionID, err)
+		http.Error(w, "failed to complete the payment", http.StatusBadRequest)
+		return
+	}
+
+	redirectURL := p.config.SuccessURL
+	if outcome != OutcomeSuccess {
+		redirectURL = p.config.CancelURL
+	}
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
+
+func (p *Processor) handleSetupPage(w http.ResponseWriter, r *http.Request) {
+	p.mutex.Lock()
+	s, ok := p.setups[r.PathValue("setupID")]
+	var data struct {
+		Completed bool
+	}
+	if ok {
+		data.Completed = s.Completed
+	}
+	p.mutex.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := setupPage.Execute(w, data); err != nil {
+		log.Printf("Failed to render fake setup page: %v", err)
+	}
+}
+
+func (p *Processor) handleSetupSubmit(w http.ResponseWriter, r *http.Request) {
+	setupID := r.PathValue("setupID")
+
+	if Outcome(r.FormValue("outcome")) != OutcomeSuccess {
+		http.Redirect(w, r, p.config.SetupCancelURL, http.StatusSeeOther)
+		return
+	}
+
+	if err := p.completeSetup(r.Context(), setupID); err != nil {
+		log.Printf("Failed to complete fake setup session %s: %v", setupID, err)
+		http.Error(w, "failed to save the card", http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, p.config.SetupSuccessURL, http.StatusSeeOther)
+}
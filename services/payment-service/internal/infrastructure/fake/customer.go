@@ -0,0 +1,145 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	"github.com/tenteedee/mini-uber/shared/retry"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+
+	"github.com/google/uuid"
+)
+
+// setupSession saves a test card on a customer, the counterpart of a Stripe Checkout session in setup mode
+type setupSession struct {
+	ID         string
+	CustomerID string
+	Metadata   map[string]string
+	Completed  bool
+}
+
+func (p *Processor) CreateCustomer(ctx context.Context, userID string) (string, error) {
+	return "fake_cus_" + uuid.New().String(), nil
+}
+
+func (p *Processor) CreateSetupSession(ctx context.Context, customerID string, metadata map[string]string) (*types.CheckoutSession, error) {
+	s := &setupSession{
+		ID:         "fake_seti_" + uuid.New().String(),
+		CustomerID: customerID,
+		Metadata:   metadata,
+	}
+
+	p.mutex.Lock()
+	p.setups[s.ID] = s
+	p.mutex.Unlock()
+
+	// only a successful outcome saves the card on its own, the others leave it to the setup page
+	if p.config.Outcome == OutcomeSuccess {
+		time.AfterFunc(p.config.Delay, func() {
+			if err := p.completeSetup(context.Background(), s.ID); err != nil {
+				log.Printf("Failed to complete fake setup session %s: %v", s.ID, err)
+			}
+		})
+	}
+
+	log.Printf("Created fake setup session %s for customer %s", s.ID, customerID)
+
+	return &types.CheckoutSession{
+		ID:  s.ID,
+		URL: p.config.CheckoutBaseURL + "/fake/setup/" + s.ID,
+	}, nil
+}
+
+// ChargePaymentMethod settles the charge immediately with the configured charge outcome. Successful
+// charges are kept as paid sessions, so they can be looked up and refunded like checkout payments.
+func (p *Processor) ChargePaymentMethod(
+	ctx context.Context,
+	customerID string,
+	paymentMethodID string,
+	amount sharedTypes.Money,
+	metadata map[string]string,
+	idempotencyKey string,
+) (*types.Charge, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if charge, ok := p.charges[idempotencyKey]; ok {
+		result := *charge
+		return &result, nil
+	}
+
+	charge := &types.Charge{
+		ID:     "fake_pi_" + uuid.New().String(),
+		Status: p.config.ChargeOutcome,
+	}
+
+	switch charge.Status {
+	case types.ChargeStatusSucceeded:
+		p.sessions[charge.ID] = &session{
+			ID:       charge.ID,
+			Amount:   amount,
+			Metadata: metadata,
+			Status:   types.PaymentStatusSuccess,
+		}
+	case types.ChargeStatusRequiresAction:
+		charge.Reason = "card requires authentication (simulated)"
+	default:
+		charge.Reason = "card declined (simulated)"
+	}
+
+	p.charges[idempotencyKey] = charge
+	log.Printf("Charged %s to fake payment method %s of %s: %s", amount, paymentMethodID, customerID, charge.Status)
+
+	result := *charge
+	return &result, nil
+}
+
+func (p *Processor) DetachPaymentMethod(ctx context.Context, paymentMethodID string) error {
+	log.Printf("Detached fake payment method %s", paymentMethodID)
+	return nil
+}
+
+// completeSetup saves a test card and reports it to the webhook endpoint
+func (p *Processor) completeSetup(ctx context.Context, setupID string) error {
+	p.mutex.Lock()
+	s, ok := p.setups[setupID]
+	if !ok {
+		p.mutex.Unlock()
+		return fmt.Errorf("unknown setup session %s", setupID)
+	}
+	if s.Completed {
+		p.mutex.Unlock()
+		return nil
+	}
+	s.Completed = true
+	metadata := s.Metadata
+	p.mutex.Unlock()
+
+	expires := time.Now().AddDate(3, 0, 0)
+	event := webhookEvent{
+		ID:        "fake_evt_" + uuid.New().String(),
+		Type:      types.PaymentEventMethodSaved,
+		SessionID: setupID,
+		Metadata:  metadata,
+		PaymentMethod: &types.PaymentMethod{
+			ID:       "fake_pm_" + uuid.New().String(),
+			Brand:    "visa",
+			Last4:    "4242",
+			ExpMonth: int64(expires.Month()),
+			ExpYear:  int64(expires.Year()),
+		},
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return retry.WithBackoff(ctx, retry.DefaultConfig(), func() error {
+		return p.sendWebhook(ctx, body)
+	})
+}
@@ -0,0 +1,276 @@
+/*
+Package fake implements a payment processor that never leaves the process, so the
+whole trip -> payment -> paid flow can run offline (local development, CI).
+
+Sessions are kept in memory and paid on a minimal hosted checkout page, or completed
+automatically after a delay. The outcome is reported back to the payment service the
+same way Stripe does it: as a signed webhook posted to the webhook endpoint.
+*/
+package fake
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	"github.com/tenteedee/mini-uber/shared/retry"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+
+	"github.com/google/uuid"
+)
+
+// SignatureHeader carries the hex encoded HMAC-SHA256 of the webhook body
+const SignatureHeader = "Fake-Signature"
+
+// Outcome is what happens to a session that is completed automatically
+type Outcome string
+
+const (
+	// OutcomeManual leaves the session open until it is paid on the checkout page
+	OutcomeManual  Outcome = "manual"
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	OutcomeCancel  Outcome = "cancel"
+)
+
+type Config struct {
+	// CheckoutBaseURL is where the browser reaches the checkout page, e.g. http://localhost:8084
+	CheckoutBaseURL string
+	// WebhookURL receives the signed payment outcomes, e.g. http://localhost:8084/webhook/fake
+	WebhookURL    string
+	WebhookSecret string
+	Outcome       Outcome
+	// Delay before an automatic outcome is applied
+	Delay      time.Duration
+	SuccessURL string
+	CancelURL  string
+	// ChargeOutcome is what happens when a saved payment method is charged
+	ChargeOutcome   types.ChargeStatus
+	SetupSuccessURL string
+	SetupCancelURL  string
+}
+
+type session struct {
+	ID       string
+	Amount   sharedTypes.Money
+	Metadata map[string]string
+	Status   types.PaymentStatus
+	Refunded int64 // in the minor unit of Amount
+}
+
+// webhookEvent is the body of the webhooks sent by the fake processor
+type webhookEvent struct {
+	ID            string                 `json:"id"`
+	Type          types.PaymentEventType `json:"type"`
+	SessionID     string                 `json:"session_id"`
+	Metadata      map[string]string      `json:"metadata"`
+	Reason        string                 `json:"reason,omitempty"`
+	PaymentMethod *types.PaymentMethod   `json:"payment_method,omitempty"`
+}
+
+type Processor struct {
+	config   Config
+	client   *http.Client
+	sessions map[string]*session
+	setups   map[string]*setupSession
+	// charges remembers the charge made under each idempotency key, as Stripe does
+	charges map[string]*types.Charge
+	mutex   sync.Mutex
+}
+
+func NewProcessor(cfg Config) *Processor {
+	if cfg.Outcome == "" {
+		cfg.Outcome = OutcomeManual
+	}
+	if cfg.ChargeOutcome == "" {
+		cfg.ChargeOutcome = types.ChargeStatusSucceeded
+	}
+
+	return &Processor{
+		config:   cfg,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		sessions: make(map[string]*session),
+		setups:   make(map[string]*setupSession),
+		charges:  make(map[string]*types.Charge),
+	}
+}
+
+func (p *Processor) CreatePaymentSession(ctx context.Context, amount sharedTypes.Money, metadata map[string]string) (*types.CheckoutSession, error) {
+	s := &session{
+		ID:       "fake_cs_" + uuid.New().String(),
+		Amount:   amount,
+		Metadata: metadata,
+		Status:   types.PaymentStatusPending,
+	}
+
+	p.mutex.Lock()
+	p.sessions[s.ID] = s
+	p.mutex.Unlock()
+
+	if p.config.Outcome != OutcomeManual {
+		time.AfterFunc(p.config.Delay, func() {
+			if err := p.complete(context.Background(), s.ID, p.config.Outcome); err != nil {
+				log.Printf("Failed to complete fake payment session %s: %v", s.ID, err)
+			}
+		})
+	}
+
+	log.Printf("Created fake payment session %s (outcome: %s)", s.ID, p.config.Outcome)
+
+	return &types.CheckoutSession{
+		ID:  s.ID,
+		URL: p.config.CheckoutBaseURL + "/fake/checkout/" + s.ID,
+	}, nil
+}
+
+func (p *Processor) ParseWebhookEvent(payload []byte, signature string) (*types.PaymentEvent, error) {
+	if p.config.WebhookSecret == "" {
+		return nil, domain.ErrWebhookNotConfigured
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.sign(payload)) {
+		return nil, fmt.Errorf("%w: signature mismatch", domain.ErrInvalidWebhook)
+	}
+
+	var event webhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidWebhook, err)
+	}
+
+	log.Printf("Received fake payment event %s: %s", event.ID, event.Type)
+
+	return &types.PaymentEvent{
+		Type:          event.Type,
+		SessionID:     event.SessionID,
+		Metadata:      event.Metadata,
+		Reason:        event.Reason,
+		PaymentMethod: event.PaymentMethod,
+	}, nil
+}
+
+func (p *Processor) GetSessionStatus(ctx context.Context, sessionID string) (types.PaymentStatus, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	s, ok := p.sessions[sessionID]
+	if !ok {
+		// sessions only live in memory, they are gone after a restart and nobody can pay them anymore:
+		// reported as expired, so the reconciliation job closes their payments instead of asking again forever
+		log.Printf("Fake session %s is unknown, it was lost on a restart: reporting it cancelled", sessionID)
+		return types.PaymentStatusCancelled, nil
+	}
+
+	return s.Status, nil
+}
+
+func (p *Processor) Refund(ctx context.Context, sessionID string, amount sharedTypes.Money, reason string) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	s, ok := p.sessions[sessionID]
+	if !ok {
+		return "", fmt.Errorf("unknown session %s", sessionID)
+	}
+
+	if s.Status != types.PaymentStatusSuccess {
+		return "", fmt.Errorf("session %s is %s, only paid sessions can be refunded", sessionID, s.Status)
+	}
+
+	if amount.Currency != s.Amount.Currency || s.Refunded+amount.Amount > s.Amount.Amount {
+		return "", fmt.Errorf("refund of %s exceeds what is left on session %s", amount, sessionID)
+	}
+	s.Refunded += amount.Amount
+
+	refundID := "fake_re_" + uuid.New().String()
+	log.Printf("Refunded %s on fake payment session %s (%s): %s", amount, sessionID, reason, refundID)
+
+	return refundID, nil
+}
+
+// complete settles an open session and posts the outcome to the webhook endpoint
+func (p *Processor) complete(ctx context.Context, sessionID string, outcome Outcome) error {
+	event := webhookEvent{
+		ID:        "fake_evt_" + uuid.New().String(),
+		SessionID: sessionID,
+	}
+
+	var status types.PaymentStatus
+	switch outcome {
+	case OutcomeSuccess:
+		event.Type = types.PaymentEventSucceeded
+		status = types.PaymentStatusSuccess
+	case OutcomeFailure:
+		event.Type = types.PaymentEventFailed
+		event.Reason = "card declined (simulated)"
+		status = types.PaymentStatusFailed
+	case OutcomeCancel:
+		event.Type = types.PaymentEventCancelled
+		event.Reason = "checkout cancelled (simulated)"
+		status = types.PaymentStatusCancelled
+	default:
+		return fmt.Errorf("unknown outcome %q", outcome)
+	}
+
+	p.mutex.Lock()
+	s, ok := p.sessions[sessionID]
+	if !ok {
+		p.mutex.Unlock()
+		return fmt.Errorf("unknown session %s", sessionID)
+	}
+	if s.Status != types.PaymentStatusPending {
+		p.mutex.Unlock()
+		return nil
+	}
+	s.Status = status
+	event.Metadata = s.Metadata
+	p.mutex.Unlock()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// retried like a real processor would, the webhook endpoint is idempotent
+	return retry.WithBackoff(ctx, retry.DefaultConfig(), func() error {
+		return p.sendWebhook(ctx, body)
+	})
+}
+
+func (p *Processor) sendWebhook(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, hex.EncodeToString(p.sign(body)))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return errors.New("webhook endpoint responded with " + resp.Status)
+	}
+
+	return nil
+}
+
+func (p *Processor) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.config.WebhookSecret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,29 @@
+package grpc
+
+import (
+	"errors"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/grpcerr"
+	"google.golang.org/grpc/codes"
+)
+
+// toStatusError translates a domain error into a gRPC status error with a stable reason.
+// Unknown errors become Internal without leaking their message to the caller.
+func toStatusError(err error) error {
+	switch {
+	case grpcerr.IsStatus(err):
+		return err
+	case errors.Is(err, domain.ErrPaymentNotFound):
+		return grpcerr.New(codes.NotFound, contracts.ErrCodePaymentNotFound, "payment not found")
+	case errors.Is(err, domain.ErrPaymentNotRefundable):
+		return grpcerr.New(codes.FailedPrecondition, contracts.ErrCodePaymentNotRefundable, "only successful payments can be refunded")
+	case errors.Is(err, domain.ErrInvalidRefundAmount):
+		return grpcerr.New(codes.InvalidArgument, contracts.ErrCodeInvalidRefundAmount, "refund amount exceeds what is left to refund")
+	case errors.Is(err, domain.ErrPaymentMethodNotFound):
+		return grpcerr.New(codes.NotFound, contracts.ErrCodePaymentMethodNotFound, "payment method not found")
+	default:
+		return grpcerr.New(codes.Internal, contracts.ErrCodeInternal, "internal error")
+	}
+}
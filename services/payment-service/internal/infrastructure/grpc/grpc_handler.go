@@ -0,0 +1,206 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/grpcerr"
+	pb "github.com/tenteedee/mini-uber/shared/proto/payment"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+	"github.com/tenteedee/mini-uber/shared/validation"
+
+	"google.golang.org/grpc"
+)
+
+type gRPCHandler struct {
+	pb.UnimplementedPaymentServiceServer
+	service  domain.Service
+	earnings domain.EarningsService
+}
+
+// maxEarningsRange bounds how much of the ledger a single earnings request reads
+const maxEarningsRange = 366 * 24 * time.Hour
+
+func NewgRPCHandler(server *grpc.Server, service domain.Service, earnings domain.EarningsService) *gRPCHandler {
+	handler := &gRPCHandler{
+		service:  service,
+		earnings: earnings,
+	}
+
+	pb.RegisterPaymentServiceServer(server, handler)
+	return handler
+}
+
+func (h *gRPCHandler) GetPayment(ctx context.Context, req *pb.GetPaymentRequest) (*pb.GetPaymentResponse, error) {
+	v := validation.New()
+	v.Required("paymentID", req.GetPaymentID())
+	if !v.Valid() {
+		return nil, grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid get payment request", v.Errors())
+	}
+
+	payment, err := h.service.GetPayment(ctx, req.GetPaymentID())
+	if err != nil {
+		log.Printf("failed to get payment %s: %v", req.GetPaymentID(), err)
+		return nil, toStatusError(err)
+	}
+
+	return &pb.GetPaymentResponse{
+		Payment: domain.ToPaymentProto(payment),
+	}, nil
+}
+
+func (h *gRPCHandler) ListPaymentsForTrip(ctx context.Context, req *pb.ListPaymentsForTripRequest) (*pb.ListPaymentsForTripResponse, error) {
+	v := validation.New()
+	v.Required("tripID", req.GetTripID())
+	if !v.Valid() {
+		return nil, grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid list payments request", v.Errors())
+	}
+
+	payments, err := h.service.ListPaymentsForTrip(ctx, req.GetTripID())
+	if err != nil {
+		log.Printf("failed to list payments for trip %s: %v", req.GetTripID(), err)
+		return nil, toStatusError(err)
+	}
+
+	return &pb.ListPaymentsForTripResponse{
+		Payments: domain.ToPaymentsProto(payments),
+	}, nil
+}
+
+func (h *gRPCHandler) RefundPayment(ctx context.Context, req *pb.RefundPaymentRequest) (*pb.RefundPaymentResponse, error) {
+	v := validation.New()
+	v.Required("paymentID", req.GetPaymentID())
+	v.Required("reason", req.GetReason())
+	amount := sharedTypes.MoneyFromProto(req.GetAmount())
+	if amount.IsNegative() {
+		v.AddError("amount.amount", "must not be negative")
+	}
+	if !amount.IsZero() && amount.Currency == "" {
+		v.AddError("amount.currency", "is required")
+	}
+	if !v.Valid() {
+		return nil, grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid refund request", v.Errors())
+	}
+
+	refund, payment, err := h.service.RefundPayment(ctx, req.GetPaymentID(), amount, req.GetReason())
+	if err != nil {
+		log.Printf("failed to refund payment %s: %v", req.GetPaymentID(), err)
+		return nil, toStatusError(err)
+	}
+
+	return &pb.RefundPaymentResponse{
+		Refund:  domain.ToRefundProto(refund),
+		Payment: domain.ToPaymentProto(payment),
+	}, nil
+}
+
+func (h *gRPCHandler) GetDriverEarnings(ctx context.Context, req *pb.GetDriverEarningsRequest) (*pb.GetDriverEarningsResponse, error) {
+	to := time.Now()
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+	from := to.Add(-30 * 24 * time.Hour)
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime()
+	}
+
+	period := types.EarningsPeriod(req.GetGroupBy())
+	if period == "" {
+		period = types.EarningsPeriodDay
+	}
+
+	v := validation.New()
+	v.Required("driverID", req.GetDriverID())
+	if period != types.EarningsPeriodDay && period != types.EarningsPeriodWeek {
+		v.AddError("groupBy", "must be day or week")
+	}
+	if !from.Before(to) {
+		v.AddError("from", "must be before to")
+	} else if to.Sub(from) > maxEarningsRange {
+		v.AddError("from", "range must not be longer than a year")
+	}
+	if !v.Valid() {
+		return nil, grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid get driver earnings request", v.Errors())
+	}
+
+	earnings, err := h.earnings.GetDriverEarnings(ctx, req.GetDriverID(), from, to, period, req.GetCurrency())
+	if err != nil {
+		log.Printf("failed to get earnings of driver %s: %v", req.GetDriverID(), err)
+		return nil, toStatusError(err)
+	}
+
+	return domain.ToDriverEarningsProto(earnings), nil
+}
+
+func (h *gRPCHandler) CreateSetupSession(ctx context.Context, req *pb.CreateSetupSessionRequest) (*pb.CreateSetupSessionResponse, error) {
+	v := validation.New()
+	v.Required("userID", req.GetUserID())
+	if !v.Valid() {
+		return nil, grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid create setup session request", v.Errors())
+	}
+
+	session, err := h.service.CreateSetupSession(ctx, req.GetUserID())
+	if err != nil {
+		log.Printf("failed to create setup session for rider %s: %v", req.GetUserID(), err)
+		return nil, toStatusError(err)
+	}
+
+	return &pb.CreateSetupSessionResponse{
+		SessionID: session.ID,
+		SetupURL:  session.URL,
+	}, nil
+}
+
+func (h *gRPCHandler) ListPaymentMethods(ctx context.Context, req *pb.ListPaymentMethodsRequest) (*pb.ListPaymentMethodsResponse, error) {
+	v := validation.New()
+	v.Required("userID", req.GetUserID())
+	if !v.Valid() {
+		return nil, grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid list payment methods request", v.Errors())
+	}
+
+	customer, err := h.service.GetCustomer(ctx, req.GetUserID())
+	if err != nil {
+		log.Printf("failed to list payment methods of rider %s: %v", req.GetUserID(), err)
+		return nil, toStatusError(err)
+	}
+
+	return domain.ToPaymentMethodsProto(customer), nil
+}
+
+func (h *gRPCHandler) SetDefaultPaymentMethod(ctx context.Context, req *pb.SetDefaultPaymentMethodRequest) (*pb.ListPaymentMethodsResponse, error) {
+	v := validation.New()
+	v.Required("userID", req.GetUserID())
+	v.Required("paymentMethodID", req.GetPaymentMethodID())
+	if !v.Valid() {
+		return nil, grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid set default payment method request", v.Errors())
+	}
+
+	customer, err := h.service.SetDefaultPaymentMethod(ctx, req.GetUserID(), req.GetPaymentMethodID())
+	if err != nil {
+		log.Printf("failed to set default payment method of rider %s: %v", req.GetUserID(), err)
+		return nil, toStatusError(err)
+	}
+
+	return domain.ToPaymentMethodsProto(customer), nil
+}
+
+func (h *gRPCHandler) RemovePaymentMethod(ctx context.Context, req *pb.RemovePaymentMethodRequest) (*pb.ListPaymentMethodsResponse, error) {
+	v := validation.New()
+	v.Required("userID", req.GetUserID())
+	v.Required("paymentMethodID", req.GetPaymentMethodID())
+	if !v.Valid() {
+		return nil, grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid remove payment method request", v.Errors())
+	}
+
+	customer, err := h.service.RemovePaymentMethod(ctx, req.GetUserID(), req.GetPaymentMethodID())
+	if err != nil {
+		log.Printf("failed to remove payment method %s of rider %s: %v", req.GetPaymentMethodID(), req.GetUserID(), err)
+		return nil, toStatusError(err)
+	}
+
+	return domain.ToPaymentMethodsProto(customer), nil
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+)
+
+// Payouts periodically pays drivers out their balance. Each run is a batch named after the
+// interval it falls in, so replicas running the job at the same time create the batch once.
+type Payouts struct {
+	earnings domain.EarningsService
+	interval time.Duration
+}
+
+func NewPayouts(earnings domain.EarningsService, interval time.Duration) *Payouts {
+	return &Payouts{
+		earnings: earnings,
+		interval: interval,
+	}
+}
+
+// Run blocks until the context is cancelled
+func (p *Payouts) Run(ctx context.Context) {
+	log.Printf("Paying out driver balances every %v", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			batchID := now.UTC().Truncate(p.interval).Format("20060102T150405Z")
+
+			payouts, err := p.earnings.CreatePayouts(ctx, batchID)
+			if err != nil {
+				log.Printf("Failed to create payout batch %s: %v", batchID, err)
+				continue
+			}
+			if len(payouts) > 0 {
+				log.Printf("Created %d payouts in batch %s", len(payouts), batchID)
+			}
+		}
+	}
+}
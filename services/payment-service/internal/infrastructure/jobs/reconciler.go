@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+)
+
+// Reconciler periodically settles payments whose webhooks never arrived
+// (endpoint down, misconfigured secret, dropped delivery...)
+type Reconciler struct {
+	service  domain.Service
+	interval time.Duration
+	// minAge leaves recent sessions alone, the rider may still be on the checkout page
+	minAge time.Duration
+}
+
+func NewReconciler(service domain.Service, interval, minAge time.Duration) *Reconciler {
+	return &Reconciler{
+		service:  service,
+		interval: interval,
+		minAge:   minAge,
+	}
+}
+
+// Run blocks until the context is cancelled
+func (r *Reconciler) Run(ctx context.Context) {
+	log.Printf("Reconciling pending payments older than %v every %v", r.minAge, r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			settled, err := r.service.ReconcilePendingPayments(ctx, r.minAge)
+			if err != nil {
+				log.Printf("Failed to reconcile pending payments: %v", err)
+				continue
+			}
+			if settled > 0 {
+				log.Printf("Reconciled %d pending payments", settled)
+			}
+		}
+	}
+}
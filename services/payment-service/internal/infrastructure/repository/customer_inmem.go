@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+)
+
+type inmemCustomerRepository struct {
+	customers map[string]*types.Customer
+	mutex     sync.RWMutex
+}
+
+func NewInmemCustomerRepository() *inmemCustomerRepository {
+	return &inmemCustomerRepository{
+		customers: make(map[string]*types.Customer),
+	}
+}
+
+func (r *inmemCustomerRepository) GetCustomer(ctx context.Context, userID string) (*types.Customer, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	customer, ok := r.customers[userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrCustomerNotFound, userID)
+	}
+
+	return copyCustomer(customer), nil
+}
+
+func (r *inmemCustomerRepository) CreateCustomer(ctx context.Context, customer *types.Customer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.customers[customer.UserID]; exists {
+		return fmt.Errorf("%w: %s", domain.ErrCustomerExists, customer.UserID)
+	}
+
+	r.customers[customer.UserID] = copyCustomer(customer)
+	return nil
+}
+
+func (r *inmemCustomerRepository) AddPaymentMethod(ctx context.Context, userID string, method *types.PaymentMethod) (*types.Customer, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	customer, ok := r.customers[userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrCustomerNotFound, userID)
+	}
+
+	if customer.PaymentMethod(method.ID) == nil {
+		customer.PaymentMethods = append(customer.PaymentMethods, *method)
+		if customer.DefaultPaymentMethodID == "" {
+			customer.DefaultPaymentMethodID = method.ID
+		}
+		customer.UpdatedAt = time.Now()
+	}
+
+	return copyCustomer(customer), nil
+}
+
+func (r *inmemCustomerRepository) SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID string) (*types.Customer, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	customer, ok := r.customers[userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrCustomerNotFound, userID)
+	}
+
+	if customer.PaymentMethod(paymentMethodID) == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPaymentMethodNotFound, paymentMethodID)
+	}
+
+	customer.DefaultPaymentMethodID = paymentMethodID
+	customer.UpdatedAt = time.Now()
+	return copyCustomer(customer), nil
+}
+
+func (r *inmemCustomerRepository) RemovePaymentMethod(ctx context.Context, userID, paymentMethodID string) (*types.Customer, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	customer, ok := r.customers[userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrCustomerNotFound, userID)
+	}
+
+	if customer.PaymentMethod(paymentMethodID) == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPaymentMethodNotFound, paymentMethodID)
+	}
+
+	methods := []types.PaymentMethod{}
+	for _, method := range customer.PaymentMethods {
+		if method.ID != paymentMethodID {
+			methods = append(methods, method)
+		}
+	}
+	customer.PaymentMethods = methods
+
+	if customer.DefaultPaymentMethodID == paymentMethodID {
+		customer.DefaultPaymentMethodID = ""
+		if len(methods) > 0 {
+			customer.DefaultPaymentMethodID = methods[len(methods)-1].ID
+		}
+	}
+	customer.UpdatedAt = time.Now()
+
+	return copyCustomer(customer), nil
+}
+
+func copyCustomer(customer *types.Customer) *types.Customer {
+	result := *customer
+	result.PaymentMethods = append([]types.PaymentMethod{}, customer.PaymentMethods...)
+	return &result
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	"github.com/tenteedee/mini-uber/shared/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoCustomerRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoCustomerRepository(db *mongo.Database) *mongoCustomerRepository {
+	return &mongoCustomerRepository{db: db}
+}
+
+func (r *mongoCustomerRepository) GetCustomer(ctx context.Context, userID string) (*types.Customer, error) {
+	result := r.db.Collection(db.CustomersCollection).FindOne(ctx, bson.M{"_id": userID})
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrCustomerNotFound, userID)
+		}
+		return nil, result.Err()
+	}
+
+	var customer types.Customer
+	if err := result.Decode(&customer); err != nil {
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
+func (r *mongoCustomerRepository) CreateCustomer(ctx context.Context, customer *types.Customer) error {
+	_, err := r.db.Collection(db.CustomersCollection).InsertOne(ctx, customer)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %s", domain.ErrCustomerExists, customer.UserID)
+	}
+	return err
+}
+
+func (r *mongoCustomerRepository) AddPaymentMethod(ctx context.Context, userID string, method *types.PaymentMethod) (*types.Customer, error) {
+	// one pipeline update appends the method and makes it the default when there is none, a method saved
+	// twice (webhook retries) doesn't match the filter and is left alone
+	_, err := r.db.Collection(db.CustomersCollection).UpdateOne(ctx,
+		bson.M{"_id": userID, "paymentMethods.id": bson.M{"$ne": method.ID}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"paymentMethods": bson.M{"$concatArrays": bson.A{
+					bson.M{"$ifNull": bson.A{"$paymentMethods", bson.A{}}},
+					bson.A{bson.M{"$literal": method}},
+				}},
+				"defaultPaymentMethodId": bson.M{"$cond": bson.A{
+					bson.M{"$in": bson.A{bson.M{"$ifNull": bson.A{"$defaultPaymentMethodId", ""}}, bson.A{""}}},
+					method.ID,
+					"$defaultPaymentMethodId",
+				}},
+				"updatedAt": time.Now(),
+			}}},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetCustomer(ctx, userID)
+}
+
+func (r *mongoCustomerRepository) SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID string) (*types.Customer, error) {
+	result, err := r.db.Collection(db.CustomersCollection).UpdateOne(ctx,
+		bson.M{"_id": userID, "paymentMethods.id": paymentMethodID},
+		bson.M{"$set": bson.M{
+			"defaultPaymentMethodId": paymentMethodID,
+			"updatedAt":              time.Now(),
+		}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.afterMethodUpdate(ctx, userID, paymentMethodID, result.MatchedCount)
+}
+
+func (r *mongoCustomerRepository) RemovePaymentMethod(ctx context.Context, userID, paymentMethodID string) (*types.Customer, error) {
+	result, err := r.db.Collection(db.CustomersCollection).UpdateOne(ctx,
+		bson.M{"_id": userID, "paymentMethods.id": paymentMethodID},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"paymentMethods": bson.M{"$filter": bson.M{
+					"input": "$paymentMethods",
+					"cond":  bson.M{"$ne": bson.A{"$$this.id", paymentMethodID}},
+				}},
+				"updatedAt": time.Now(),
+			}}},
+			// the methods were filtered by the previous stage, the last one left is the most recently saved
+			{{Key: "$set", Value: bson.M{
+				"defaultPaymentMethodId": bson.M{"$cond": bson.A{
+					bson.M{"$eq": bson.A{"$defaultPaymentMethodId", paymentMethodID}},
+					bson.M{"$ifNull": bson.A{bson.M{"$last": "$paymentMethods.id"}, ""}},
+					"$defaultPaymentMethodId",
+				}},
+			}}},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.afterMethodUpdate(ctx, userID, paymentMethodID, result.MatchedCount)
+}
+
+// afterMethodUpdate returns the updated customer, or why the update matched nothing
+func (r *mongoCustomerRepository) afterMethodUpdate(ctx context.Context, userID, paymentMethodID string, matched int64) (*types.Customer, error) {
+	customer, err := r.GetCustomer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if matched == 0 {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPaymentMethodNotFound, paymentMethodID)
+	}
+
+	return customer, nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+)
+
+// inmemRepository keeps payments in memory, webhooks and queue consumers update it concurrently
+type inmemRepository struct {
+	payments map[string]*types.Payment
+	refunds  map[string]*types.Refund
+	mutex    sync.RWMutex
+}
+
+func NewInmemRepository() *inmemRepository {
+	return &inmemRepository{
+		payments: make(map[string]*types.Payment),
+		refunds:  make(map[string]*types.Refund),
+	}
+}
+
+func (r *inmemRepository) CreatePayment(ctx context.Context, payment *types.Payment) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.payments[payment.ID]; exists {
+		return fmt.Errorf("payment %s already exists", payment.ID)
+	}
+
+	stored := *payment
+	r.payments[payment.ID] = &stored
+	return nil
+}
+
+func (r *inmemRepository) GetPaymentByID(ctx context.Context, id string) (*types.Payment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	payment, ok := r.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPaymentNotFound, id)
+	}
+
+	result := *payment
+	return &result, nil
+}
+
+func (r *inmemRepository) GetPaymentBySessionID(ctx context.Context, sessionID string) (*types.Payment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, payment := range r.payments {
+		if payment.StripeSessionID == sessionID {
+			result := *payment
+			return &result, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", domain.ErrPaymentNotFound, sessionID)
+}
+
+func (r *inmemRepository) ListPaymentsByTripID(ctx context.Context, tripID string) ([]*types.Payment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	payments := []*types.Payment{}
+	for _, payment := range r.payments {
+		if payment.TripID == tripID {
+			result := *payment
+			payments = append(payments, &result)
+		}
+	}
+
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].CreatedAt.Before(payments[j].CreatedAt)
+	})
+
+	return payments, nil
+}
+
+func (r *inmemRepository) ListPaymentsByStatus(ctx context.Context, status types.PaymentStatus, createdBefore time.Time, limit int) ([]*types.Payment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	payments := []*types.Payment{}
+	for _, payment := range r.payments {
+		if payment.Status == status && payment.CreatedAt.Before(createdBefore) && payment.StripeSessionID != "" {
+			result := *payment
+			payments = append(payments, &result)
+		}
+	}
+
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].CreatedAt.Before(payments[j].CreatedAt)
+	})
+
+	if len(payments) > limit {
+		payments = payments[:limit]
+	}
+
+	return payments, nil
+}
+
+func (r *inmemRepository) UpdatePaymentStatus(ctx context.Context, id string, expected, next types.PaymentStatus, reason string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	payment, ok := r.payments[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrPaymentNotFound, id)
+	}
+
+	if payment.Status != expected {
+		return fmt.Errorf("%w: %s is no longer %s", domain.ErrPaymentStatusConflict, id, expected)
+	}
+
+	payment.Status = next
+	payment.FailureReason = reason
+	payment.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *inmemRepository) AddRefundedAmount(ctx context.Context, id string, amount sharedTypes.Money) (*types.Payment, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	payment, ok := r.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPaymentNotFound, id)
+	}
+
+	if payment.Status != types.PaymentStatusSuccess {
+		return nil, fmt.Errorf("%w: payment %s is %s", domain.ErrPaymentNotRefundable, id, payment.Status)
+	}
+
+	refunded, err := payment.RefundedAmount.Add(amount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidRefundAmount, err)
+	}
+
+	if refunded.Amount > payment.Amount.Amount {
+		return nil, fmt.Errorf("%w: %s of %s already refunded", domain.ErrInvalidRefundAmount, payment.RefundedAmount, payment.Amount)
+	}
+
+	payment.RefundedAmount = refunded
+	payment.UpdatedAt = time.Now()
+
+	result := *payment
+	return &result, nil
+}
+
+func (r *inmemRepository) CreateRefund(ctx context.Context, refund *types.Refund) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *refund
+	r.refunds[refund.ID] = &stored
+	return nil
+}
+
+func (r *inmemRepository) SetPaymentSession(ctx context.Context, id, sessionID, checkoutURL string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	payment, ok := r.payments[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrPaymentNotFound, id)
+	}
+
+	payment.StripeSessionID = sessionID
+	payment.CheckoutURL = checkoutURL
+	payment.UpdatedAt = time.Now()
+	return nil
+}
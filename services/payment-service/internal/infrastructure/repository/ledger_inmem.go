@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+)
+
+// inmemLedgerRepository keeps the ledger in memory, entries are never changed once posted
+type inmemLedgerRepository struct {
+	entries map[string]*types.JournalEntry
+	payouts map[string]*types.Payout
+	mutex   sync.RWMutex
+}
+
+func NewInmemLedgerRepository() *inmemLedgerRepository {
+	return &inmemLedgerRepository{
+		entries: make(map[string]*types.JournalEntry),
+		payouts: make(map[string]*types.Payout),
+	}
+}
+
+func (r *inmemLedgerRepository) PostEntry(ctx context.Context, entry *types.JournalEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.entries[entry.ID]; exists {
+		return fmt.Errorf("%w: %s", domain.ErrEntryExists, entry.ID)
+	}
+
+	stored := *entry
+	stored.Lines = append([]types.LedgerLine(nil), entry.Lines...)
+	r.entries[entry.ID] = &stored
+	return nil
+}
+
+func (r *inmemLedgerRepository) GetEntry(ctx context.Context, id string) (*types.JournalEntry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entry, ok := r.entries[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrEntryNotFound, id)
+	}
+
+	result := *entry
+	return &result, nil
+}
+
+func (r *inmemLedgerRepository) ListDriverEntries(ctx context.Context, driverID string, from, to time.Time) ([]*types.JournalEntry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entries := []*types.JournalEntry{}
+	for _, entry := range r.entries {
+		if entry.DriverID == driverID && !entry.CreatedAt.Before(from) && entry.CreatedAt.Before(to) {
+			result := *entry
+			entries = append(entries, &result)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+
+	return entries, nil
+}
+
+func (r *inmemLedgerRepository) GetLatestDriverEntry(ctx context.Context, driverID string) (*types.JournalEntry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var latest *types.JournalEntry
+	for _, entry := range r.entries {
+		if entry.DriverID == driverID && (latest == nil || entry.CreatedAt.After(latest.CreatedAt)) {
+			latest = entry
+		}
+	}
+
+	if latest == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrEntryNotFound, driverID)
+	}
+
+	result := *latest
+	return &result, nil
+}
+
+func (r *inmemLedgerRepository) GetAccountBalance(ctx context.Context, account string, currency string) (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var balance int64
+	for _, entry := range r.entries {
+		if entry.Currency == currency {
+			balance += entry.Credited(account) - entry.Debited(account)
+		}
+	}
+
+	return balance, nil
+}
+
+func (r *inmemLedgerRepository) ListDriverBalances(ctx context.Context) ([]*types.DriverBalance, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	type key struct{ driverID, currency string }
+	totals := make(map[key]int64)
+	for _, entry := range r.entries {
+		for _, line := range entry.Lines {
+			if driverID, ok := types.DriverIDFromAccount(line.Account); ok {
+				totals[key{driverID, entry.Currency}] += line.Credit - line.Debit
+			}
+		}
+	}
+
+	balances := make([]*types.DriverBalance, 0, len(totals))
+	for k, total := range totals {
+		balances = append(balances, &types.DriverBalance{
+			DriverID: k.driverID,
+			Balance:  sharedTypes.NewMoney(total, k.currency),
+		})
+	}
+
+	return balances, nil
+}
+
+func (r *inmemLedgerRepository) CreatePayout(ctx context.Context, payout *types.Payout) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *payout
+	r.payouts[payout.ID] = &stored
+	return nil
+}
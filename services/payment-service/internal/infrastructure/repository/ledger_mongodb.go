@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	"github.com/tenteedee/mini-uber/shared/db"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoLedgerRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoLedgerRepository(db *mongo.Database) *mongoLedgerRepository {
+	return &mongoLedgerRepository{db: db}
+}
+
+func (r *mongoLedgerRepository) PostEntry(ctx context.Context, entry *types.JournalEntry) error {
+	// entry ids are derived from what they record, the unique _id makes posting idempotent
+	_, err := r.db.Collection(db.LedgerEntriesCollection).InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %s", domain.ErrEntryExists, entry.ID)
+	}
+	return err
+}
+
+func (r *mongoLedgerRepository) GetEntry(ctx context.Context, id string) (*types.JournalEntry, error) {
+	return r.findOne(ctx, bson.M{"_id": id}, id)
+}
+
+func (r *mongoLedgerRepository) ListDriverEntries(ctx context.Context, driverID string, from, to time.Time) ([]*types.JournalEntry, error) {
+	cursor, err := r.db.Collection(db.LedgerEntriesCollection).Find(ctx,
+		bson.M{"driverId": driverID, "createdAt": bson.M{"$gte": from, "$lt": to}},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*types.JournalEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (r *mongoLedgerRepository) GetLatestDriverEntry(ctx context.Context, driverID string) (*types.JournalEntry, error) {
+	return r.findOne(ctx,
+		bson.M{"driverId": driverID},
+		driverID,
+		options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	)
+}
+
+func (r *mongoLedgerRepository) GetAccountBalance(ctx context.Context, account string, currency string) (int64, error) {
+	balances, err := r.balances(ctx, bson.M{"lines.account": account, "currency": currency})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(balances) == 0 {
+		return 0, nil
+	}
+	return balances[0].Balance, nil
+}
+
+func (r *mongoLedgerRepository) ListDriverBalances(ctx context.Context) ([]*types.DriverBalance, error) {
+	balances, err := r.balances(ctx, bson.M{"lines.account": bson.M{"$regex": "^" + types.DriverAccount("")}})
+	if err != nil {
+		return nil, err
+	}
+
+	driverBalances := make([]*types.DriverBalance, 0, len(balances))
+	for _, balance := range balances {
+		driverID, ok := types.DriverIDFromAccount(balance.ID.Account)
+		if !ok {
+			continue
+		}
+		driverBalances = append(driverBalances, &types.DriverBalance{
+			DriverID: driverID,
+			Balance:  sharedTypes.NewMoney(balance.Balance, balance.ID.Currency),
+		})
+	}
+
+	return driverBalances, nil
+}
+
+func (r *mongoLedgerRepository) CreatePayout(ctx context.Context, payout *types.Payout) error {
+	_, err := r.db.Collection(db.PayoutsCollection).InsertOne(ctx, payout)
+	return err
+}
+
+type accountBalance struct {
+	ID struct {
+		Account  string `bson:"account"`
+		Currency string `bson:"currency"`
+	} `bson:"_id"`
+	Balance int64 `bson:"balance"`
+}
+
+// balances sums credits minus debits of the lines matching the filter, per account and currency
+func (r *mongoLedgerRepository) balances(ctx context.Context, lineFilter bson.M) ([]*accountBalance, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: lineFilter}},
+		{{Key: "$unwind", Value: "$lines"}},
+		{{Key: "$match", Value: lineFilter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     bson.M{"account": "$lines.account", "currency": "$currency"},
+			"balance": bson.M{"$sum": bson.M{"$subtract": bson.A{"$lines.credit", "$lines.debit"}}},
+		}}},
+	}
+
+	cursor, err := r.db.Collection(db.LedgerEntriesCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	balances := []*accountBalance{}
+	if err := cursor.All(ctx, &balances); err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+func (r *mongoLedgerRepository) findOne(ctx context.Context, filter bson.M, key string, opts ...*options.FindOneOptions) (*types.JournalEntry, error) {
+	result := r.db.Collection(db.LedgerEntriesCollection).FindOne(ctx, filter, opts...)
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrEntryNotFound, key)
+		}
+		return nil, result.Err()
+	}
+
+	var entry types.JournalEntry
+	if err := result.Decode(&entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	"github.com/tenteedee/mini-uber/shared/db"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoRepository(db *mongo.Database) *mongoRepository {
+	return &mongoRepository{db: db}
+}
+
+func (r *mongoRepository) CreatePayment(ctx context.Context, payment *types.Payment) error {
+	_, err := r.db.Collection(db.PaymentsCollection).InsertOne(ctx, payment)
+	return err
+}
+
+func (r *mongoRepository) GetPaymentByID(ctx context.Context, id string) (*types.Payment, error) {
+	return r.findOne(ctx, bson.M{"_id": id}, id)
+}
+
+func (r *mongoRepository) GetPaymentBySessionID(ctx context.Context, sessionID string) (*types.Payment, error) {
+	return r.findOne(ctx, bson.M{"stripeSessionId": sessionID}, sessionID)
+}
+
+func (r *mongoRepository) ListPaymentsByTripID(ctx context.Context, tripID string) ([]*types.Payment, error) {
+	cursor, err := r.db.Collection(db.PaymentsCollection).Find(ctx,
+		bson.M{"tripId": tripID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	payments := []*types.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (r *mongoRepository) ListPaymentsByStatus(ctx context.Context, status types.PaymentStatus, createdBefore time.Time, limit int) ([]*types.Payment, error) {
+	cursor, err := r.db.Collection(db.PaymentsCollection).Find(ctx,
+		bson.M{"status": status, "createdAt": bson.M{"$lt": createdBefore}, "stripeSessionId": bson.M{"$ne": ""}},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	payments := []*types.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (r *mongoRepository) UpdatePaymentStatus(ctx context.Context, id string, expected, next types.PaymentStatus, reason string) error {
+	result, err := r.db.Collection(db.PaymentsCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "status": expected},
+		bson.M{"$set": bson.M{
+			"status":        next,
+			"failureReason": reason,
+			"updatedAt":     time.Now(),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := r.GetPaymentByID(ctx, id); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s is no longer %s", domain.ErrPaymentStatusConflict, id, expected)
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) AddRefundedAmount(ctx context.Context, id string, amount sharedTypes.Money) (*types.Payment, error) {
+	// the check and the increment happen in one update, so concurrent refunds can't exceed the payment
+	filter := bson.M{
+		"_id":             id,
+		"status":          types.PaymentStatusSuccess,
+		"amount.currency": amount.Currency,
+		"$expr": bson.M{"$lte": bson.A{
+			bson.M{"$add": bson.A{"$refundedAmount.amount", amount.Amount}},
+			"$amount.amount",
+		}},
+	}
+
+	result := r.db.Collection(db.PaymentsCollection).FindOneAndUpdate(ctx,
+		filter,
+		bson.M{
+			"$inc": bson.M{"refundedAmount.amount": amount.Amount},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+	if result.Err() != nil {
+		if !errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return nil, result.Err()
+		}
+
+		payment, err := r.GetPaymentByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if payment.Status != types.PaymentStatusSuccess {
+			return nil, fmt.Errorf("%w: payment %s is %s", domain.ErrPaymentNotRefundable, id, payment.Status)
+		}
+		return nil, fmt.Errorf("%w: %s of %s already refunded", domain.ErrInvalidRefundAmount, payment.RefundedAmount, payment.Amount)
+	}
+
+	var payment types.Payment
+	if err := result.Decode(&payment); err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (r *mongoRepository) CreateRefund(ctx context.Context, refund *types.Refund) error {
+	_, err := r.db.Collection(db.RefundsCollection).InsertOne(ctx, refund)
+	return err
+}
+
+func (r *mongoRepository) SetPaymentSession(ctx context.Context, id, sessionID, checkoutURL string) error {
+	result, err := r.db.Collection(db.PaymentsCollection).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"stripeSessionId": sessionID,
+			"checkoutUrl":     checkoutURL,
+			"updatedAt":       time.Now(),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrPaymentNotFound, id)
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) findOne(ctx context.Context, filter bson.M, key string) (*types.Payment, error) {
+	result := r.db.Collection(db.PaymentsCollection).FindOne(ctx, filter)
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrPaymentNotFound, key)
+		}
+		return nil, result.Err()
+	}
+
+	var payment types.Payment
+	if err := result.Decode(&payment); err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
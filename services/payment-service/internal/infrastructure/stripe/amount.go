@@ -0,0 +1,55 @@
+package stripe
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+)
+
+// stripeZeroDecimal are the currencies Stripe charges in whole units, e.g. 25000 is 25,000 VND.
+// See https://docs.stripe.com/currencies#zero-decimal
+var stripeZeroDecimal = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "JPY": true, "KMF": true, "KRW": true, "MGA": true,
+	"PYG": true, "RWF": true, "VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+// stripeExponent returns the number of decimals Stripe expects amounts of the currency in.
+// ISK and UGX have no minor unit, but for backwards compatibility Stripe still takes them
+// with two decimals that must be 00.
+func stripeExponent(currency string) int {
+	switch {
+	case stripeZeroDecimal[currency]:
+		return 0
+	case currency == "ISK" || currency == "UGX":
+		return 2
+	default:
+		return sharedTypes.CurrencyExponent(currency)
+	}
+}
+
+// toStripeAmount converts money, kept in the ISO 4217 minor unit of its currency, into
+// the unit Stripe expects for that currency.
+func toStripeAmount(amount sharedTypes.Money) (int64, error) {
+	currency := strings.ToUpper(amount.Currency)
+	shift := stripeExponent(currency) - sharedTypes.CurrencyExponent(currency)
+
+	value := amount.Amount
+	if shift > 0 {
+		value *= int64(math.Pow10(shift))
+	} else if shift < 0 {
+		scale := int64(math.Pow10(-shift))
+		if value%scale != 0 {
+			return 0, fmt.Errorf("%s cannot be charged on Stripe, %s is charged in whole units", amount, currency)
+		}
+		value /= scale
+	}
+
+	// three decimal currencies are charged in multiples of 10
+	if stripeExponent(currency) == 3 && value%10 != 0 {
+		return 0, fmt.Errorf("%s cannot be charged on Stripe, the last decimal of %s must be 0", amount, currency)
+	}
+
+	return value, nil
+}
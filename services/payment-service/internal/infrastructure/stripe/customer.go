@@ -0,0 +1,162 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/checkout/session"
+	"github.com/stripe/stripe-go/v81/customer"
+	"github.com/stripe/stripe-go/v81/paymentintent"
+	"github.com/stripe/stripe-go/v81/paymentmethod"
+	"github.com/stripe/stripe-go/v81/setupintent"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+)
+
+// offSessionMetadataKey marks the payment intents of saved payment method charges, their
+// outcome is known when the charge is made so their webhooks are ignored
+const offSessionMetadataKey = "off_session"
+
+func (s *StripeClient) CreateCustomer(ctx context.Context, userID string) (string, error) {
+	params := &stripe.CustomerParams{}
+	params.Context = ctx
+	params.AddMetadata("user_id", userID)
+	// a retried setup request must not leave the rider with two customers
+	params.SetIdempotencyKey("customer:" + userID)
+
+	created, err := customer.New(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to create a customer on Stripe: %v", err)
+	}
+
+	return created.ID, nil
+}
+
+func (s *StripeClient) CreateSetupSession(ctx context.Context, customerID string, metadata map[string]string) (*types.CheckoutSession, error) {
+	params := &stripe.CheckoutSessionParams{
+		Customer:   stripe.String(customerID),
+		SuccessURL: stripe.String(s.config.SetupSuccessURL),
+		CancelURL:  stripe.String(s.config.SetupCancelURL),
+		Metadata:   metadata,
+		// only cards can be charged off session without the rider
+		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
+		SetupIntentData: &stripe.CheckoutSessionSetupIntentDataParams{
+			Metadata: metadata,
+		},
+		Mode: stripe.String(string(stripe.CheckoutSessionModeSetup)),
+	}
+	params.Context = ctx
+
+	result, err := session.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a setup session on Stripe: %v", err)
+	}
+
+	return &types.CheckoutSession{
+		ID:  result.ID,
+		URL: result.URL,
+	}, nil
+}
+
+func (s *StripeClient) ChargePaymentMethod(
+	ctx context.Context,
+	customerID string,
+	paymentMethodID string,
+	amount sharedTypes.Money,
+	metadata map[string]string,
+	idempotencyKey string,
+) (*types.Charge, error) {
+	stripeAmount, err := toStripeAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &stripe.PaymentIntentParams{
+		Amount:        stripe.Int64(stripeAmount),
+		Currency:      stripe.String(strings.ToLower(amount.Currency)),
+		Customer:      stripe.String(customerID),
+		PaymentMethod: stripe.String(paymentMethodID),
+		OffSession:    stripe.Bool(true),
+		Confirm:       stripe.Bool(true),
+	}
+	params.Context = ctx
+	params.SetIdempotencyKey(idempotencyKey)
+	for key, value := range metadata {
+		params.AddMetadata(key, value)
+	}
+	params.AddMetadata(offSessionMetadataKey, "true")
+
+	intent, err := paymentintent.New(params)
+	if err != nil {
+		// declines and authentication requests are card errors, anything else never reached the card
+		var stripeErr *stripe.Error
+		if !errors.As(err, &stripeErr) || stripeErr.Type != stripe.ErrorTypeCard {
+			return nil, fmt.Errorf("failed to charge payment method %s on Stripe: %v", paymentMethodID, err)
+		}
+
+		charge := &types.Charge{Status: types.ChargeStatusFailed, Reason: stripeErr.Msg}
+		if stripeErr.PaymentIntent != nil {
+			charge.ID = stripeErr.PaymentIntent.ID
+		}
+		if stripeErr.Code == stripe.ErrorCodeAuthenticationRequired {
+			charge.Status = types.ChargeStatusRequiresAction
+		}
+		return charge, nil
+	}
+
+	switch intent.Status {
+	case stripe.PaymentIntentStatusSucceeded:
+		return &types.Charge{ID: intent.ID, Status: types.ChargeStatusSucceeded}, nil
+	case stripe.PaymentIntentStatusRequiresAction:
+		return &types.Charge{ID: intent.ID, Status: types.ChargeStatusRequiresAction, Reason: "the card requires authentication"}, nil
+	default:
+		return &types.Charge{ID: intent.ID, Status: types.ChargeStatusFailed, Reason: fmt.Sprintf("payment is %s", intent.Status)}, nil
+	}
+}
+
+func (s *StripeClient) DetachPaymentMethod(ctx context.Context, paymentMethodID string) error {
+	params := &stripe.PaymentMethodDetachParams{}
+	params.Context = ctx
+
+	if _, err := paymentmethod.Detach(paymentMethodID, params); err != nil {
+		return fmt.Errorf("failed to detach payment method %s on Stripe: %v", paymentMethodID, err)
+	}
+	return nil
+}
+
+// savedPaymentMethod returns the card saved by a completed setup session
+func savedPaymentMethod(setupSession *stripe.CheckoutSession) (*types.PaymentMethod, error) {
+	if setupSession.SetupIntent == nil {
+		return nil, fmt.Errorf("setup session %s has no setup intent", setupSession.ID)
+	}
+
+	params := &stripe.SetupIntentParams{}
+	params.AddExpand("payment_method")
+
+	intent, err := setupintent.Get(setupSession.SetupIntent.ID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get setup intent %s from Stripe: %v", setupSession.SetupIntent.ID, err)
+	}
+
+	if intent.PaymentMethod == nil {
+		return nil, fmt.Errorf("setup intent %s saved no payment method", intent.ID)
+	}
+
+	method := &types.PaymentMethod{ID: intent.PaymentMethod.ID}
+	if card := intent.PaymentMethod.Card; card != nil {
+		method.Brand = string(card.Brand)
+		method.Last4 = card.Last4
+		method.ExpMonth = card.ExpMonth
+		method.ExpYear = card.ExpYear
+	}
+
+	return method, nil
+}
+
+// isCharge tells the payment intents of off-session charges from checkout sessions
+func isCharge(sessionID string) bool {
+	return strings.HasPrefix(sessionID, "pi_")
+}
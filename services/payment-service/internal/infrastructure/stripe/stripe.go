@@ -3,11 +3,15 @@ package stripe
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/stripe/stripe-go/v81"
 	"github.com/stripe/stripe-go/v81/checkout/session"
+	"github.com/stripe/stripe-go/v81/paymentintent"
+	"github.com/stripe/stripe-go/v81/refund"
 	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
 	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
 )
 
 type StripeClient struct {
@@ -21,7 +25,12 @@ func NewStripeClient(cfg *types.PaymentConfig) domain.PaymentProcessor {
 	}
 }
 
-func (s *StripeClient) CreatePaymentSession(ctx context.Context, amount int64, currency string, metadata map[string]string) (string, error) {
+func (s *StripeClient) CreatePaymentSession(ctx context.Context, amount sharedTypes.Money, metadata map[string]string) (*types.CheckoutSession, error) {
+	unitAmount, err := toStripeAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+
 	params := &stripe.CheckoutSessionParams{
 		SuccessURL: stripe.String(s.config.SuccessURL),
 		CancelURL:  stripe.String(s.config.CancelURL),
@@ -29,21 +38,119 @@ func (s *StripeClient) CreatePaymentSession(ctx context.Context, amount int64, c
 		LineItems: []*stripe.CheckoutSessionLineItemParams{
 			{
 				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-					Currency: stripe.String(currency),
+					Currency: stripe.String(strings.ToLower(amount.Currency)),
 					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
 						Name: stripe.String("Ride Payment"),
 					},
-					UnitAmount: stripe.Int64(amount),
+					UnitAmount: stripe.Int64(unitAmount),
 				},
 				Quantity: stripe.Int64(1),
 			},
 		},
+		// copy the trip metadata onto the payment intent as well, so payment_intent.* webhooks can be matched to the trip
+		PaymentIntentData: &stripe.CheckoutSessionPaymentIntentDataParams{
+			Metadata: metadata,
+		},
 		Mode: stripe.String(string(stripe.CheckoutSessionModePayment)),
 	}
 	result, err := session.New(params)
 	if err != nil {
-		return "", fmt.Errorf("failed to create a payment session on Stripe: %v", err)
+		return nil, fmt.Errorf("failed to create a payment session on Stripe: %v", err)
+	}
+
+	return &types.CheckoutSession{
+		ID:  result.ID,
+		URL: result.URL,
+	}, nil
+}
+
+func (s *StripeClient) GetSessionStatus(ctx context.Context, sessionID string) (types.PaymentStatus, error) {
+	if isCharge(sessionID) {
+		return s.getChargeStatus(ctx, sessionID)
+	}
+
+	params := &stripe.CheckoutSessionParams{}
+	params.Context = ctx
+	params.AddExpand("payment_intent")
+
+	result, err := session.Get(sessionID, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to get payment session %s from Stripe: %v", sessionID, err)
 	}
 
-	return result.ID, nil
+	switch result.Status {
+	case stripe.CheckoutSessionStatusExpired:
+		return types.PaymentStatusCancelled, nil
+	case stripe.CheckoutSessionStatusComplete:
+		if result.PaymentStatus != stripe.CheckoutSessionPaymentStatusUnpaid {
+			return types.PaymentStatusSuccess, nil
+		}
+
+		// completed with a delayed payment method, the payment intent tells whether the money arrived
+		if result.PaymentIntent != nil && result.PaymentIntent.Status == stripe.PaymentIntentStatusCanceled {
+			return types.PaymentStatusFailed, nil
+		}
+		return types.PaymentStatusPending, nil
+	default:
+		return types.PaymentStatusPending, nil
+	}
+}
+
+func (s *StripeClient) Refund(ctx context.Context, sessionID string, amount sharedTypes.Money, reason string) (string, error) {
+	refundAmount, err := toStripeAmount(amount)
+	if err != nil {
+		return "", err
+	}
+
+	// saved payment methods are charged without a session, on a payment intent
+	paymentIntentID := sessionID
+	if !isCharge(sessionID) {
+		sessionParams := &stripe.CheckoutSessionParams{}
+		sessionParams.Context = ctx
+
+		result, err := session.Get(sessionID, sessionParams)
+		if err != nil {
+			return "", fmt.Errorf("failed to get payment session %s from Stripe: %v", sessionID, err)
+		}
+
+		if result.PaymentIntent == nil {
+			return "", fmt.Errorf("payment session %s has no payment to refund", sessionID)
+		}
+		paymentIntentID = result.PaymentIntent.ID
+	}
+
+	// Stripe only accepts a few fixed reasons, the free text one is kept in the metadata
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(paymentIntentID),
+		Amount:        stripe.Int64(refundAmount),
+		Reason:        stripe.String(string(stripe.RefundReasonRequestedByCustomer)),
+	}
+	params.Context = ctx
+	params.AddMetadata("reason", reason)
+
+	created, err := refund.New(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to refund payment session %s on Stripe: %v", sessionID, err)
+	}
+
+	return created.ID, nil
+}
+
+func (s *StripeClient) getChargeStatus(ctx context.Context, paymentIntentID string) (types.PaymentStatus, error) {
+	params := &stripe.PaymentIntentParams{}
+	params.Context = ctx
+
+	intent, err := paymentintent.Get(paymentIntentID, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to get payment intent %s from Stripe: %v", paymentIntentID, err)
+	}
+
+	switch intent.Status {
+	case stripe.PaymentIntentStatusSucceeded:
+		return types.PaymentStatusSuccess, nil
+	case stripe.PaymentIntentStatusCanceled, stripe.PaymentIntentStatusRequiresPaymentMethod:
+		return types.PaymentStatusFailed, nil
+	default:
+		return types.PaymentStatusPending, nil
+	}
 }
@@ -0,0 +1,130 @@
+package stripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/webhook"
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+)
+
+func (s *StripeClient) ParseWebhookEvent(payload []byte, signature string) (*types.PaymentEvent, error) {
+	if s.config.StripeWebhookSecret == "" {
+		return nil, domain.ErrWebhookNotConfigured
+	}
+
+	event, err := webhook.ConstructEventWithOptions(
+		payload,
+		signature,
+		s.config.StripeWebhookSecret,
+		webhook.ConstructEventOptions{
+			IgnoreAPIVersionMismatch: true,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidWebhook, err)
+	}
+
+	log.Printf("Received Stripe event %s: %s", event.ID, event.Type)
+
+	switch event.Type {
+	case stripe.EventTypeCheckoutSessionCompleted:
+		session, err := parseCheckoutSession(event)
+		if err != nil {
+			return nil, err
+		}
+
+		if session.Mode == stripe.CheckoutSessionModeSetup {
+			method, err := savedPaymentMethod(session)
+			if err != nil {
+				return nil, err
+			}
+			return &types.PaymentEvent{
+				Type:          types.PaymentEventMethodSaved,
+				SessionID:     session.ID,
+				Metadata:      session.Metadata,
+				PaymentMethod: method,
+			}, nil
+		}
+
+		// delayed payment methods (e.g. bank debits) complete the checkout before the money
+		// arrives, the outcome is then reported by one of the async payment events
+		if session.PaymentStatus == stripe.CheckoutSessionPaymentStatusUnpaid {
+			log.Printf("Checkout session %s completed, awaiting asynchronous payment", session.ID)
+			return nil, nil
+		}
+
+		return sessionEvent(types.PaymentEventSucceeded, session, ""), nil
+
+	case stripe.EventTypeCheckoutSessionAsyncPaymentSucceeded:
+		session, err := parseCheckoutSession(event)
+		if err != nil {
+			return nil, err
+		}
+		return sessionEvent(types.PaymentEventSucceeded, session, ""), nil
+
+	case stripe.EventTypeCheckoutSessionAsyncPaymentFailed:
+		session, err := parseCheckoutSession(event)
+		if err != nil {
+			return nil, err
+		}
+		return sessionEvent(types.PaymentEventFailed, session, "asynchronous payment failed"), nil
+
+	case stripe.EventTypeCheckoutSessionExpired:
+		session, err := parseCheckoutSession(event)
+		if err != nil {
+			return nil, err
+		}
+
+		// nothing was saved on an abandoned setup session, and nothing is waiting for it
+		if session.Mode == stripe.CheckoutSessionModeSetup {
+			return nil, nil
+		}
+		return sessionEvent(types.PaymentEventCancelled, session, "checkout session expired"), nil
+
+	case stripe.EventTypePaymentIntentPaymentFailed:
+		var intent stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &intent); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidWebhook, err)
+		}
+
+		// a declined charge of a saved payment method falls back to checkout, it must not fail the payment
+		if intent.Metadata[offSessionMetadataKey] == "true" {
+			return nil, nil
+		}
+
+		reason := "payment failed"
+		if intent.LastPaymentError != nil && intent.LastPaymentError.Msg != "" {
+			reason = intent.LastPaymentError.Msg
+		}
+
+		// the session id isn't known from the payment intent, the trip metadata is copied onto it on creation
+		return &types.PaymentEvent{
+			Type:     types.PaymentEventFailed,
+			Metadata: intent.Metadata,
+			Reason:   reason,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func parseCheckoutSession(event stripe.Event) (*stripe.CheckoutSession, error) {
+	var session stripe.CheckoutSession
+	if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidWebhook, err)
+	}
+	return &session, nil
+}
+
+func sessionEvent(eventType types.PaymentEventType, session *stripe.CheckoutSession, reason string) *types.PaymentEvent {
+	return &types.PaymentEvent{
+		Type:      eventType,
+		SessionID: session.ID,
+		Metadata:  session.Metadata,
+		Reason:    reason,
+	}
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/infrastructure/fake"
+	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/tracing"
+)
+
+// stripe webhook payloads are small, this only protects against abuse of the public endpoint
+const maxBodyBytes = 65536
+
+var tracer = tracing.GetTracer("payment-service")
+
+type Handler struct {
+	service domain.Service
+}
+
+func NewHandler(service domain.Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes adds the webhook endpoints to the mux. The api-gateway proxies
+// the public /webhook/stripe route to this service unchanged, /webhook/fake is only
+// called by the fake processor from within this service.
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("POST /webhook/stripe", tracing.WrapHandlerFunc(h.webhookHandler("Stripe-Signature"), "/webhook/stripe"))
+	mux.Handle("POST /webhook/fake", tracing.WrapHandlerFunc(h.webhookHandler(fake.SignatureHeader), "/webhook/fake"))
+}
+
+// webhookHandler verifies webhooks with the configured payment processor, the signature is read from signatureHeader
+func (h *Handler) webhookHandler(signatureHeader string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.handleWebhook(w, r, r.Header.Get(signatureHeader))
+	}
+}
+
+func (h *Handler) handleWebhook(w http.ResponseWriter, r *http.Request, signature string) {
+	ctx, span := tracer.Start(r.Context(), "handleWebhook")
+	defer span.End()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidRequest, "failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.service.HandleWebhook(ctx, body, signature); err != nil {
+		log.Printf("Failed to handle payment webhook: %v", err)
+
+		switch {
+		case errors.Is(err, domain.ErrWebhookNotConfigured):
+			// not a 2xx, so that Stripe keeps retrying until the secret is configured
+			writeError(w, http.StatusServiceUnavailable, contracts.ErrCodeUnavailable, "webhook is not configured")
+		case errors.Is(err, domain.ErrInvalidWebhook):
+			writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidRequest, "invalid webhook")
+		default:
+			writeError(w, http.StatusInternalServerError, contracts.ErrCodeInternal, "internal error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(contracts.APIResponse{
+		Error: &contracts.APIError{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+
+	"github.com/google/uuid"
+)
+
+// defaultEarningsRange is used when GetDriverEarnings is not given a start
+const defaultEarningsRange = 30 * 24 * time.Hour
+
+type earningsService struct {
+	ledger domain.LedgerRepository
+	config *types.EarningsConfig
+}
+
+// NewEarningsService creates the service keeping the driver earnings ledger
+func NewEarningsService(ledger domain.LedgerRepository, config *types.EarningsConfig) domain.EarningsService {
+	return &earningsService{
+		ledger: ledger,
+		config: config,
+	}
+}
+
+// RecordPayment credits the driver with their share of a successful payment. Fares and
+// cancellation fees pay the commission of their car package, tips go to the driver in full.
+func (s *earningsService) RecordPayment(ctx context.Context, payment *types.Payment) error {
+	if payment.DriverID == "" {
+		log.Printf("Payment %s of trip %s has no driver, nothing to record in the ledger", payment.ID, payment.TripID)
+		return nil
+	}
+
+	kind := types.EntryKindFare
+	commission := int64(0)
+	switch payment.Kind {
+	case types.PaymentKindTip:
+		kind = types.EntryKindTip
+	case types.PaymentKindCancellationFee:
+		kind = types.EntryKindCancellationFee
+		commission = s.commission(payment)
+	default:
+		commission = s.commission(payment)
+	}
+
+	lines := types.NonZeroLines(
+		types.LedgerLine{Account: types.AccountCash, Debit: payment.Amount.Amount},
+		types.LedgerLine{Account: types.DriverAccount(payment.DriverID), Credit: payment.Amount.Amount - commission},
+		types.LedgerLine{Account: types.AccountCommission, Credit: commission},
+	)
+
+	return s.post(ctx, &types.JournalEntry{
+		ID:        paymentEntryID(payment.ID),
+		Kind:      kind,
+		Currency:  payment.Amount.Currency,
+		TripID:    payment.TripID,
+		PaymentID: payment.ID,
+		DriverID:  payment.DriverID,
+		Lines:     lines,
+		CreatedAt: time.Now(),
+	})
+}
+
+// RecordRefund takes the refund back from the driver and the platform in the proportion
+// the payment was split, so refunding a whole fare also gives back the whole commission
+func (s *earningsService) RecordRefund(ctx context.Context, refund *types.Refund, payment *types.Payment) error {
+	original, err := s.ledger.GetEntry(ctx, paymentEntryID(payment.ID))
+	if errors.Is(err, domain.ErrEntryNotFound) {
+		log.Printf("Payment %s was never recorded in the ledger, ignoring refund %s", payment.ID, refund.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	amount := refund.Amount.Amount
+	commission := int64(0)
+	if paid := original.Debited(types.AccountCash); paid > 0 {
+		commission = int64(math.Round(float64(amount) * float64(original.Credited(types.AccountCommission)) / float64(paid)))
+	}
+
+	lines := types.NonZeroLines(
+		types.LedgerLine{Account: types.AccountCash, Credit: amount},
+		types.LedgerLine{Account: types.DriverAccount(original.DriverID), Debit: amount - commission},
+		types.LedgerLine{Account: types.AccountCommission, Debit: commission},
+	)
+
+	return s.post(ctx, &types.JournalEntry{
+		ID:        "refund:" + refund.ID,
+		Kind:      types.EntryKindRefund,
+		Currency:  refund.Amount.Currency,
+		TripID:    payment.TripID,
+		PaymentID: payment.ID,
+		DriverID:  original.DriverID,
+		Lines:     lines,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *earningsService) GetDriverEarnings(ctx context.Context, driverID string, from, to time.Time, period types.EarningsPeriod, currency string) (*types.DriverEarnings, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultEarningsRange)
+	}
+
+	if currency == "" {
+		latest, err := s.ledger.GetLatestDriverEntry(ctx, driverID)
+		switch {
+		case err == nil:
+			currency = latest.Currency
+		case errors.Is(err, domain.ErrEntryNotFound):
+			currency = sharedTypes.DefaultCurrency
+		default:
+			return nil, err
+		}
+	}
+
+	entries, err := s.ledger.ListDriverEntries(ctx, driverID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger entries of driver %s: %w", driverID, err)
+	}
+
+	balance, err := s.ledger.GetAccountBalance(ctx, types.DriverAccount(driverID), currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance of driver %s: %w", driverID, err)
+	}
+
+	earnings := &types.DriverEarnings{
+		DriverID: driverID,
+		Currency: currency,
+		From:     from,
+		To:       to,
+		Totals:   types.NewEarningsTotals(currency),
+		Periods:  []*types.PeriodEarnings{},
+		Trips:    []*types.TripEarnings{},
+		Balance:  sharedTypes.NewMoney(balance, currency),
+	}
+
+	periods := make(map[time.Time]*types.PeriodEarnings)
+	trips := make(map[string]*types.TripEarnings)
+
+	for _, entry := range entries {
+		if entry.Currency != currency {
+			continue
+		}
+
+		earnings.Totals.Add(entry)
+
+		start := period.Start(entry.CreatedAt)
+		if _, ok := periods[start]; !ok {
+			periods[start] = &types.PeriodEarnings{Start: start, Totals: types.NewEarningsTotals(currency)}
+			earnings.Periods = append(earnings.Periods, periods[start])
+		}
+		periods[start].Totals.Add(entry)
+
+		// payouts are not tied to a trip
+		if entry.TripID == "" {
+			continue
+		}
+		if _, ok := trips[entry.TripID]; !ok {
+			trips[entry.TripID] = &types.TripEarnings{TripID: entry.TripID, Totals: types.NewEarningsTotals(currency)}
+			earnings.Trips = append(earnings.Trips, trips[entry.TripID])
+		}
+		trips[entry.TripID].Totals.Add(entry)
+	}
+
+	sort.Slice(earnings.Periods, func(i, j int) bool {
+		return earnings.Periods[i].Start.Before(earnings.Periods[j].Start)
+	})
+
+	return earnings, nil
+}
+
+// CreatePayouts moves every positive driver balance to the payouts account. The entries are keyed
+// by batch, driver and currency, so running the same batch again (e.g. on another replica) pays nothing twice.
+func (s *earningsService) CreatePayouts(ctx context.Context, batchID string) ([]*types.Payout, error) {
+	balances, err := s.ledger.ListDriverBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list driver balances: %w", err)
+	}
+
+	payouts := []*types.Payout{}
+	for _, balance := range balances {
+		if balance.Balance.Amount <= 0 {
+			continue
+		}
+
+		payout := &types.Payout{
+			ID:        uuid.New().String(),
+			BatchID:   batchID,
+			DriverID:  balance.DriverID,
+			Amount:    balance.Balance,
+			Status:    types.PayoutStatusPending,
+			CreatedAt: time.Now(),
+		}
+
+		entry := &types.JournalEntry{
+			ID:        fmt.Sprintf("payout:%s:%s:%s", batchID, balance.DriverID, balance.Balance.Currency),
+			Kind:      types.EntryKindPayout,
+			Currency:  balance.Balance.Currency,
+			DriverID:  balance.DriverID,
+			PaymentID: payout.ID,
+			Lines: []types.LedgerLine{
+				{Account: types.DriverAccount(balance.DriverID), Debit: balance.Balance.Amount},
+				{Account: types.AccountPayouts, Credit: balance.Balance.Amount},
+			},
+			CreatedAt: payout.CreatedAt,
+		}
+
+		if err := entry.Validate(); err != nil {
+			return payouts, err
+		}
+
+		err := s.ledger.PostEntry(ctx, entry)
+		if errors.Is(err, domain.ErrEntryExists) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to post payout of %s to driver %s: %v", balance.Balance, balance.DriverID, err)
+			continue
+		}
+
+		// the ledger entry references the payout, so a payout that failed to be stored can be recreated from it
+		if err := s.ledger.CreatePayout(ctx, payout); err != nil {
+			log.Printf("Failed to store payout %s of %s to driver %s: %v", payout.ID, payout.Amount, payout.DriverID, err)
+			continue
+		}
+
+		payouts = append(payouts, payout)
+	}
+
+	return payouts, nil
+}
+
+// commission is the platform's share of the payment, rounded to the minor unit
+func (s *earningsService) commission(payment *types.Payment) int64 {
+	percent := s.config.CommissionPercentFor(payment.PackageSlug)
+	return sharedTypes.RoundMoney(float64(payment.Amount.Amount)*percent/100, payment.Amount.Currency).Amount
+}
+
+// post validates and stores the entry, an entry that was already posted is not an error
+func (s *earningsService) post(ctx context.Context, entry *types.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	err := s.ledger.PostEntry(ctx, entry)
+	if errors.Is(err, domain.ErrEntryExists) {
+		log.Printf("Ledger entry %s was already posted", entry.ID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post ledger entry %s: %w", entry.ID, err)
+	}
+
+	return nil
+}
+
+func paymentEntryID(paymentID string) string {
+	return "payment:" + paymentID
+}
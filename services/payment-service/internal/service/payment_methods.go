@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
+	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+)
+
+// CreateSetupSession creates the rider's customer on the processor the first time they save a payment method
+func (s *paymentService) CreateSetupSession(ctx context.Context, userID string) (*types.CheckoutSession, error) {
+	customer, err := s.getOrCreateCustomer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.paymentProcessor.CreateSetupSession(ctx, customer.ProcessorCustomerID, map[string]string{
+		"user_id": userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create setup session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (s *paymentService) GetCustomer(ctx context.Context, userID string) (*types.Customer, error) {
+	customer, err := s.customers.GetCustomer(ctx, userID)
+	if errors.Is(err, domain.ErrCustomerNotFound) {
+		return &types.Customer{UserID: userID, PaymentMethods: []types.PaymentMethod{}}, nil
+	}
+	return customer, err
+}
+
+func (s *paymentService) SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID string) (*types.Customer, error) {
+	customer, err := s.customers.SetDefaultPaymentMethod(ctx, userID, paymentMethodID)
+	if errors.Is(err, domain.ErrCustomerNotFound) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPaymentMethodNotFound, paymentMethodID)
+	}
+	return customer, err
+}
+
+// RemovePaymentMethod stops charging the method before detaching it from the customer on the processor.
+// Fares already scheduled on it fall back to checkout when the trip completes.
+func (s *paymentService) RemovePaymentMethod(ctx context.Context, userID, paymentMethodID string) (*types.Customer, error) {
+	customer, err := s.customers.RemovePaymentMethod(ctx, userID, paymentMethodID)
+	if errors.Is(err, domain.ErrCustomerNotFound) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPaymentMethodNotFound, paymentMethodID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.paymentProcessor.DetachPaymentMethod(ctx, paymentMethodID); err != nil {
+		log.Printf("Failed to detach payment method %s of rider %s: %v", paymentMethodID, userID, err)
+	}
+
+	return customer, nil
+}
+
+// ChargeTrip falls back to a checkout session on the same payment, the rider pays it as any other fare
+func (s *paymentService) ChargeTrip(ctx context.Context, tripID string) (*types.PaymentIntent, error) {
+	payments, err := s.repo.ListPaymentsByTripID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payment, fallback *types.Payment
+	for _, p := range payments {
+		if p.Kind == types.PaymentKindTip {
+			continue
+		}
+		if p.Scheduled() {
+			payment = p
+		} else if p.FellBackToCheckout() {
+			fallback = p
+		}
+	}
+
+	if payment == nil && fallback != nil {
+		// a redelivered trip completion, whose checkout session was stored but maybe never published:
+		// the rider is sent the same session again
+		log.Printf("Trip %s already falls back to checkout session %s", tripID, fallback.StripeSessionID)
+		intent := paymentIntentFor(fallback)
+		intent.FallbackReason = "the saved payment method could not be charged"
+		return intent, nil
+	}
+
+	if payment == nil {
+		log.Printf("Trip %s has no charge scheduled on a saved payment method", tripID)
+		return nil, nil
+	}
+
+	customer, err := s.customers.GetCustomer(ctx, payment.UserID)
+	if err != nil && !errors.Is(err, domain.ErrCustomerNotFound) {
+		return nil, err
+	}
+
+	reason := "the saved payment method was removed"
+	if customer != nil && customer.PaymentMethod(payment.PaymentMethodID) != nil {
+		// keyed by payment, a redelivered trip completion gets the first charge back instead of charging again
+		charge, err := s.paymentProcessor.ChargePaymentMethod(ctx,
+			customer.ProcessorCustomerID,
+			payment.PaymentMethodID,
+			payment.Amount,
+			paymentMetadata(payment),
+			"charge:"+payment.ID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to charge payment %s: %w", payment.ID, err)
+		}
+
+		if charge.Status == types.ChargeStatusSucceeded {
+			return s.completeCharge(ctx, payment, charge)
+		}
+
+		log.Printf("Charge of payment %s to %s was not accepted (%s): %s", payment.ID, payment.PaymentMethodID, charge.Status, charge.Reason)
+		reason = charge.Reason
+	}
+
+	session, err := s.paymentProcessor.CreatePaymentSession(ctx, payment.Amount, paymentMetadata(payment))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment session: %w", err)
+	}
+
+	if err := s.repo.SetPaymentSession(ctx, payment.ID, session.ID, session.URL); err != nil {
+		return nil, fmt.Errorf("failed to store payment session of payment %s: %w", payment.ID, err)
+	}
+
+	payment.StripeSessionID = session.ID
+	payment.CheckoutURL = session.URL
+
+	intent := paymentIntentFor(payment)
+	intent.FallbackReason = reason
+	return intent, nil
+}
+
+// completeCharge records the charge on the payment before applying its success. Should applying fail,
+// the payment is no longer scheduled and the reconciliation job settles it from the charge.
+func (s *paymentService) completeCharge(ctx context.Context, payment *types.Payment, charge *types.Charge) (*types.PaymentIntent, error) {
+	if err := s.repo.SetPaymentSession(ctx, payment.ID, charge.ID, ""); err != nil {
+		return nil, fmt.Errorf("failed to store charge %s of payment %s: %w", charge.ID, payment.ID, err)
+	}
+	payment.StripeSessionID = charge.ID
+
+	event := &types.PaymentEvent{
+		Type:      types.PaymentEventSucceeded,
+		SessionID: charge.ID,
+		Metadata:  paymentMetadata(payment),
+	}
+	if err := s.applyPaymentEvent(ctx, payment, event); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Charged %s of trip %s to payment method %s", payment.Amount, payment.TripID, payment.PaymentMethodID)
+	return paymentIntentFor(payment), nil
+}
+
+// schedulePayment records the fare as pending on the saved payment method, it is charged by ChargeTrip
+func (s *paymentService) schedulePayment(ctx context.Context, payment *types.Payment, method *types.PaymentMethod) (*types.PaymentIntent, error) {
+	if payment.Amount.Amount <= 0 {
+		return nil, fmt.Errorf("invalid payment amount %s for trip %s", payment.Amount, payment.TripID)
+	}
+
+	now := time.Now()
+	payment.PaymentMethodID = method.ID
+	payment.RefundedAmount.Currency = payment.Amount.Currency
+	payment.Status = types.PaymentStatusPending
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+
+	if err := s.repo.CreatePayment(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to store payment: %w", err)
+	}
+
+	log.Printf("Fare of trip %s will be charged to %s ending in %s", payment.TripID, method.Brand, method.Last4)
+
+	intent := paymentIntentFor(payment)
+	intent.PaymentMethod = method
+	return intent, nil
+}
+
+// defaultPaymentMethod returns the method the rider's fares are charged to, or nil when they check out
+func (s *paymentService) defaultPaymentMethod(ctx context.Context, userID string) (*types.PaymentMethod, error) {
+	customer, err := s.customers.GetCustomer(ctx, userID)
+	if errors.Is(err, domain.ErrCustomerNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer %s: %w", userID, err)
+	}
+
+	return customer.DefaultPaymentMethod(), nil
+}
+
+func (s *paymentService) getOrCreateCustomer(ctx context.Context, userID string) (*types.Customer, error) {
+	customer, err := s.customers.GetCustomer(ctx, userID)
+	if !errors.Is(err, domain.ErrCustomerNotFound) {
+		return customer, err
+	}
+
+	processorCustomerID, err := s.paymentProcessor.CreateCustomer(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customer: %w", err)
+	}
+
+	now := time.Now()
+	customer = &types.Customer{
+		UserID:              userID,
+		ProcessorCustomerID: processorCustomerID,
+		PaymentMethods:      []types.PaymentMethod{},
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+
+	err = s.customers.CreateCustomer(ctx, customer)
+	if errors.Is(err, domain.ErrCustomerExists) {
+		// created concurrently by another setup request
+		return s.customers.GetCustomer(ctx, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store customer %s: %w", userID, err)
+	}
+
+	return customer, nil
+}
+
+// savePaymentMethod stores the method saved on a setup session, webhook retries save it once
+func (s *paymentService) savePaymentMethod(ctx context.Context, event *types.PaymentEvent) error {
+	userID := event.Metadata["user_id"]
+	if userID == "" || event.PaymentMethod == nil {
+		return fmt.Errorf("%w: setup session %s has no rider or payment method", domain.ErrInvalidWebhook, event.SessionID)
+	}
+
+	method := *event.PaymentMethod
+	method.CreatedAt = time.Now()
+
+	customer, err := s.customers.AddPaymentMethod(ctx, userID, &method)
+	if err != nil {
+		return fmt.Errorf("failed to save payment method %s of rider %s: %w", method.ID, userID, err)
+	}
+
+	log.Printf("Rider %s saved %s ending in %s (default: %s)", userID, method.Brand, method.Last4, customer.DefaultPaymentMethodID)
+	return nil
+}
@@ -2,56 +2,431 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/tenteedee/mini-uber/services/payment-service/internal/domain"
 	"github.com/tenteedee/mini-uber/services/payment-service/pkg/types"
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
 
 	"github.com/google/uuid"
 )
 
+// reconcileBatchSize caps the processor lookups done by a single reconciliation run
+const reconcileBatchSize = 100
+
 type paymentService struct {
 	paymentProcessor domain.PaymentProcessor
+	repo             domain.PaymentRepository
+	customers        domain.CustomerRepository
+	publisher        domain.EventPublisher
+	earnings         domain.EarningsService
 }
 
 // NewPaymentService creates a new instance of the payment service
-func NewPaymentService(paymentProcessor domain.PaymentProcessor) domain.Service {
+func NewPaymentService(
+	paymentProcessor domain.PaymentProcessor,
+	repo domain.PaymentRepository,
+	customers domain.CustomerRepository,
+	publisher domain.EventPublisher,
+	earnings domain.EarningsService,
+) domain.Service {
 	return &paymentService{
 		paymentProcessor: paymentProcessor,
+		repo:             repo,
+		customers:        customers,
+		publisher:        publisher,
+		earnings:         earnings,
 	}
 }
 
-// CreatePaymentSession creates a new payment session for a trip
+// CreatePaymentSession creates a new payment session for a trip and records it as a pending payment.
+// Riders with a default payment method don't get a session, the fare is charged to it when the trip completes.
+// The fare is recorded under an id derived from the trip, so a trip whose fare was already recorded returns
+// its existing session, or scheduled charge, instead of a new one.
 func (s *paymentService) CreatePaymentSession(
 	ctx context.Context,
 	tripID string,
 	userID string,
 	driverID string,
-	amount int64,
-	currency string,
+	packageSlug string,
+	amount sharedTypes.Money,
+) (*types.PaymentIntent, error) {
+	existing, err := s.repo.GetPaymentByID(ctx, farePaymentID(tripID))
+	if err == nil {
+		log.Printf("Fare of trip %s already has payment %s", tripID, existing.ID)
+		return s.existingFareIntent(ctx, existing)
+	}
+	if !errors.Is(err, domain.ErrPaymentNotFound) {
+		return nil, err
+	}
+
+	payment := &types.Payment{
+		ID:          farePaymentID(tripID),
+		Kind:        types.PaymentKindFare,
+		TripID:      tripID,
+		UserID:      userID,
+		DriverID:    driverID,
+		PackageSlug: packageSlug,
+		Amount:      amount,
+	}
+
+	method, err := s.defaultPaymentMethod(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if method != nil {
+		return s.schedulePayment(ctx, payment, method)
+	}
+
+	return s.createPayment(ctx, payment)
+}
+
+// existingFareIntent is the intent of a fare recorded before, scheduled on the saved payment method or paid on its session
+func (s *paymentService) existingFareIntent(ctx context.Context, payment *types.Payment) (*types.PaymentIntent, error) {
+	intent := paymentIntentFor(payment)
+	if !payment.Scheduled() {
+		return intent, nil
+	}
+
+	customer, err := s.customers.GetCustomer(ctx, payment.UserID)
+	if err != nil && !errors.Is(err, domain.ErrCustomerNotFound) {
+		return nil, err
+	}
+
+	// a method removed since falls back to checkout when the trip completes
+	intent.PaymentMethod = &types.PaymentMethod{ID: payment.PaymentMethodID}
+	if customer != nil {
+		if method := customer.PaymentMethod(payment.PaymentMethodID); method != nil {
+			intent.PaymentMethod = method
+		}
+	}
+	return intent, nil
+}
+
+// farePaymentID is the id of the fare payment of the trip, a trip has a single fare
+func farePaymentID(tripID string) string {
+	return "fare:" + tripID
+}
+
+// CreateTipSession charges a tip separately from the fare of the trip. The payment is recorded under
+// the tip id, so a tip that was already charged returns its existing session instead of a new one.
+func (s *paymentService) CreateTipSession(
+	ctx context.Context,
+	tripID string,
+	tipID string,
+	userID string,
+	driverID string,
+	amount sharedTypes.Money,
 ) (*types.PaymentIntent, error) {
-	metadata := map[string]string{
-		"trip_id":   tripID,
-		"user_id":   userID,
-		"driver_id": driverID,
+	existing, err := s.repo.GetPaymentByID(ctx, tipID)
+	if err == nil {
+		log.Printf("Tip %s of trip %s already has payment session %s", tipID, tripID, existing.StripeSessionID)
+		return paymentIntentFor(existing), nil
+	}
+	if !errors.Is(err, domain.ErrPaymentNotFound) {
+		return nil, err
+	}
+
+	return s.createPayment(ctx, &types.Payment{
+		ID:       tipID,
+		Kind:     types.PaymentKindTip,
+		TripID:   tripID,
+		UserID:   userID,
+		DriverID: driverID,
+		Amount:   amount,
+	})
+}
+
+// createPayment opens a session on the processor for the payment and records it as pending
+func (s *paymentService) createPayment(ctx context.Context, payment *types.Payment) (*types.PaymentIntent, error) {
+	if payment.Amount.Amount <= 0 {
+		return nil, fmt.Errorf("invalid payment amount %s for trip %s", payment.Amount, payment.TripID)
 	}
 
-	sessionID, err := s.paymentProcessor.CreatePaymentSession(ctx, amount, currency, metadata)
+	now := time.Now()
+	payment.RefundedAmount = sharedTypes.NewMoney(0, payment.Amount.Currency)
+	payment.Status = types.PaymentStatusPending
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+
+	session, err := s.paymentProcessor.CreatePaymentSession(ctx, payment.Amount, paymentMetadata(payment))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create payment session: %w", err)
 	}
 
-	paymentIntent := &types.PaymentIntent{
-		ID:              uuid.New().String(),
-		TripID:          tripID,
-		UserID:          userID,
-		DriverID:        driverID,
-		Amount:          amount,
-		Currency:        currency,
-		StripeSessionID: sessionID,
-		CreatedAt:       time.Now(),
+	payment.StripeSessionID = session.ID
+	payment.CheckoutURL = session.URL
+
+	if err := s.repo.CreatePayment(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to store payment: %w", err)
+	}
+
+	return paymentIntentFor(payment), nil
+}
+
+// HandleWebhook verifies a payment processor webhook and applies the payment outcome it reports.
+// Notifications that don't change the outcome, or were already applied (Stripe retries webhooks), are ignored.
+func (s *paymentService) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	event, err := s.paymentProcessor.ParseWebhookEvent(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	if event == nil {
+		return nil
+	}
+
+	if event.Type == types.PaymentEventMethodSaved {
+		return s.savePaymentMethod(ctx, event)
+	}
+
+	if event.Metadata["trip_id"] == "" {
+		return fmt.Errorf("%w: payment event %s for session %s has no trip", domain.ErrInvalidWebhook, event.Type, event.SessionID)
+	}
+
+	payment, err := s.findPaymentForEvent(ctx, event)
+	if errors.Is(err, domain.ErrPaymentNotFound) {
+		// sessions created before payments were stored, still let the trip know about the outcome
+		log.Printf("No payment recorded for trip %s, session %s", event.Metadata["trip_id"], event.SessionID)
+		return s.publisher.PublishPaymentEvent(ctx, event)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.applyPaymentEvent(ctx, payment, event)
+}
+
+func (s *paymentService) GetPayment(ctx context.Context, paymentID string) (*types.Payment, error) {
+	return s.repo.GetPaymentByID(ctx, paymentID)
+}
+
+func (s *paymentService) ListPaymentsForTrip(ctx context.Context, tripID string) ([]*types.Payment, error) {
+	return s.repo.ListPaymentsByTripID(ctx, tripID)
+}
+
+// ReconcilePendingPayments settles the payments whose webhooks were missed, so their trips don't stay unpaid forever
+func (s *paymentService) ReconcilePendingPayments(ctx context.Context, olderThan time.Duration) (int, error) {
+	payments, err := s.repo.ListPaymentsByStatus(ctx, types.PaymentStatusPending, time.Now().Add(-olderThan), reconcileBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending payments: %w", err)
+	}
+
+	settled := 0
+	for _, payment := range payments {
+		status, err := s.paymentProcessor.GetSessionStatus(ctx, payment.StripeSessionID)
+		if err != nil {
+			log.Printf("Failed to get status of payment %s: %v", payment.ID, err)
+			continue
+		}
+
+		if status == types.PaymentStatusPending {
+			continue
+		}
+
+		event := &types.PaymentEvent{
+			Type:      paymentEventFromStatus(status),
+			SessionID: payment.StripeSessionID,
+			Metadata:  paymentMetadata(payment),
+		}
+		if status != types.PaymentStatusSuccess {
+			event.Reason = fmt.Sprintf("payment %s, found by reconciliation", status)
+		}
+
+		log.Printf("Reconciling payment %s of trip %s: %s", payment.ID, payment.TripID, status)
+
+		if err := s.applyPaymentEvent(ctx, payment, event); err != nil {
+			log.Printf("Failed to reconcile payment %s: %v", payment.ID, err)
+			continue
+		}
+		settled++
+	}
+
+	return settled, nil
+}
+
+// RefundPayment reserves the amount on the payment before asking the processor for the refund,
+// so that concurrent refunds can never give back more than was paid
+func (s *paymentService) RefundPayment(ctx context.Context, paymentID string, amount sharedTypes.Money, reason string) (*types.Refund, *types.Payment, error) {
+	payment, err := s.repo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if payment.Status != types.PaymentStatusSuccess {
+		return nil, nil, fmt.Errorf("%w: payment %s is %s", domain.ErrPaymentNotRefundable, paymentID, payment.Status)
+	}
+
+	remaining, err := payment.Amount.Sub(payment.RefundedAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if amount.IsZero() {
+		amount = remaining
+	}
+
+	if amount.Currency != payment.Amount.Currency {
+		return nil, nil, fmt.Errorf("%w: refund in %s on a payment in %s", domain.ErrInvalidRefundAmount, amount.Currency, payment.Amount.Currency)
+	}
+
+	if amount.Amount <= 0 {
+		return nil, nil, fmt.Errorf("%w: nothing left to refund on payment %s", domain.ErrInvalidRefundAmount, paymentID)
+	}
+
+	payment, err = s.repo.AddRefundedAmount(ctx, paymentID, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	processorRefundID, err := s.paymentProcessor.Refund(ctx, payment.StripeSessionID, amount, reason)
+	if err != nil {
+		release := sharedTypes.NewMoney(-amount.Amount, amount.Currency)
+		if _, releaseErr := s.repo.AddRefundedAmount(ctx, paymentID, release); releaseErr != nil {
+			log.Printf("Failed to release refund of %s on payment %s: %v", amount, paymentID, releaseErr)
+		}
+		return nil, nil, fmt.Errorf("failed to refund payment %s: %w", paymentID, err)
+	}
+
+	refund := &types.Refund{
+		ID:                uuid.New().String(),
+		PaymentID:         payment.ID,
+		TripID:            payment.TripID,
+		Amount:            amount,
+		Reason:            reason,
+		ProcessorRefundID: processorRefundID,
+		CreatedAt:         time.Now(),
+	}
+
+	// the money is already on its way back, so don't fail the call from here on
+	if err := s.repo.CreateRefund(ctx, refund); err != nil {
+		log.Printf("Failed to store refund %s (%s) of payment %s: %v", refund.ID, processorRefundID, paymentID, err)
+	}
+
+	if err := s.earnings.RecordRefund(ctx, refund, payment); err != nil {
+		log.Printf("Failed to record refund %s of payment %s in the ledger: %v", refund.ID, paymentID, err)
+	}
+
+	if err := s.publisher.PublishRefundEvent(ctx, refund, payment); err != nil {
+		log.Printf("Failed to publish refund %s of payment %s: %v", refund.ID, paymentID, err)
+	}
+
+	log.Printf("Refunded %s of payment %s for trip %s: %s", amount, paymentID, payment.TripID, reason)
+	return refund, payment, nil
+}
+
+// applyPaymentEvent publishes the outcome and then moves the payment to the matching status.
+// Publishing first means a failure is retried by the caller (webhook or next reconciliation)
+// instead of being lost, consumers may therefore see the same outcome twice.
+func (s *paymentService) applyPaymentEvent(ctx context.Context, payment *types.Payment, event *types.PaymentEvent) error {
+	next := paymentStatusFromEvent(event.Type)
+	if payment.Status == next || !payment.Status.CanTransitionTo(next) {
+		log.Printf("Ignoring %s event for payment %s in status %s", event.Type, payment.ID, payment.Status)
+		return nil
+	}
+
+	if event.SessionID == "" {
+		event.SessionID = payment.StripeSessionID
+	}
+
+	// tips are reported on their own events, a tip must never look like the fare being paid
+	publish := s.publisher.PublishPaymentEvent
+	if payment.Kind == types.PaymentKindTip {
+		publish = func(ctx context.Context, event *types.PaymentEvent) error {
+			// the rider can still retry a failed payment on the same session, a new tip would be charged twice
+			if event.Type == types.PaymentEventFailed {
+				return nil
+			}
+			return s.publisher.PublishTipEvent(ctx, payment, event)
+		}
+	}
+
+	if err := publish(ctx, event); err != nil {
+		return fmt.Errorf("failed to publish %s event for payment %s: %w", event.Type, payment.ID, err)
+	}
+
+	// recorded before the status moves on, so a failure here is retried along with the event
+	if next == types.PaymentStatusSuccess {
+		if err := s.earnings.RecordPayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to record payment %s in the ledger: %w", payment.ID, err)
+		}
+	}
+
+	err := s.repo.UpdatePaymentStatus(ctx, payment.ID, payment.Status, next, event.Reason)
+	if errors.Is(err, domain.ErrPaymentStatusConflict) {
+		// applied concurrently by a webhook retry or the reconciliation job
+		log.Printf("Payment %s was updated concurrently: %v", payment.ID, err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update payment %s to %s: %w", payment.ID, next, err)
+	}
+
+	log.Printf("Payment %s of trip %s is now %s", payment.ID, payment.TripID, next)
+	return nil
+}
+
+// findPaymentForEvent looks the payment up by the id stored in the processor metadata,
+// falling back to the session for sessions created without it
+func (s *paymentService) findPaymentForEvent(ctx context.Context, event *types.PaymentEvent) (*types.Payment, error) {
+	if paymentID := event.Metadata["payment_id"]; paymentID != "" {
+		return s.repo.GetPaymentByID(ctx, paymentID)
+	}
+
+	if event.SessionID != "" {
+		return s.repo.GetPaymentBySessionID(ctx, event.SessionID)
+	}
+
+	return nil, domain.ErrPaymentNotFound
+}
+
+func paymentIntentFor(payment *types.Payment) *types.PaymentIntent {
+	return &types.PaymentIntent{
+		ID:              payment.ID,
+		TripID:          payment.TripID,
+		UserID:          payment.UserID,
+		DriverID:        payment.DriverID,
+		Amount:          payment.Amount,
+		StripeSessionID: payment.StripeSessionID,
+		CheckoutURL:     payment.CheckoutURL,
+		CreatedAt:       payment.CreatedAt,
 	}
+}
+
+// paymentMetadata is attached to the processor session, and comes back with every webhook
+func paymentMetadata(payment *types.Payment) map[string]string {
+	return map[string]string{
+		"payment_id": payment.ID,
+		"trip_id":    payment.TripID,
+		"user_id":    payment.UserID,
+		"driver_id":  payment.DriverID,
+	}
+}
 
-	return paymentIntent, nil
+func paymentStatusFromEvent(eventType types.PaymentEventType) types.PaymentStatus {
+	switch eventType {
+	case types.PaymentEventSucceeded:
+		return types.PaymentStatusSuccess
+	case types.PaymentEventFailed:
+		return types.PaymentStatusFailed
+	case types.PaymentEventCancelled:
+		return types.PaymentStatusCancelled
+	default:
+		return types.PaymentStatusPending
+	}
+}
+
+func paymentEventFromStatus(status types.PaymentStatus) types.PaymentEventType {
+	switch status {
+	case types.PaymentStatusSuccess:
+		return types.PaymentEventSucceeded
+	case types.PaymentStatusFailed:
+		return types.PaymentEventFailed
+	default:
+		return types.PaymentEventCancelled
+	}
 }
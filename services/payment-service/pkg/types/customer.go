@@ -0,0 +1,58 @@
+package types
+
+import "time"
+
+// PaymentMethod is a card the rider saved on the processor, it can be charged without the rider being present
+type PaymentMethod struct {
+	ID        string    `json:"id" bson:"id"`
+	Brand     string    `json:"brand" bson:"brand"`
+	Last4     string    `json:"last4" bson:"last4"`
+	ExpMonth  int64     `json:"exp_month" bson:"expMonth"`
+	ExpYear   int64     `json:"exp_year" bson:"expYear"`
+	CreatedAt time.Time `json:"created_at" bson:"createdAt"`
+}
+
+// Customer is the rider's account on the payment processor, along with the payment methods saved on it
+type Customer struct {
+	UserID                 string          `json:"user_id" bson:"_id"`
+	ProcessorCustomerID    string          `json:"processor_customer_id" bson:"processorCustomerId"`
+	DefaultPaymentMethodID string          `json:"default_payment_method_id,omitempty" bson:"defaultPaymentMethodId,omitempty"`
+	PaymentMethods         []PaymentMethod `json:"payment_methods" bson:"paymentMethods"`
+	CreatedAt              time.Time       `json:"created_at" bson:"createdAt"`
+	UpdatedAt              time.Time       `json:"updated_at" bson:"updatedAt"`
+}
+
+// PaymentMethod returns the saved payment method with the id, or nil
+func (c *Customer) PaymentMethod(id string) *PaymentMethod {
+	for i := range c.PaymentMethods {
+		if c.PaymentMethods[i].ID == id {
+			return &c.PaymentMethods[i]
+		}
+	}
+	return nil
+}
+
+// DefaultPaymentMethod returns the payment method fares are charged to, or nil when the rider checks out every trip
+func (c *Customer) DefaultPaymentMethod() *PaymentMethod {
+	if c.DefaultPaymentMethodID == "" {
+		return nil
+	}
+	return c.PaymentMethod(c.DefaultPaymentMethodID)
+}
+
+// ChargeStatus is the outcome of charging a saved payment method
+type ChargeStatus string
+
+const (
+	ChargeStatusSucceeded ChargeStatus = "succeeded"
+	// ChargeStatusRequiresAction charges need the rider to authenticate (e.g. 3-D Secure), they have to check out instead
+	ChargeStatusRequiresAction ChargeStatus = "requires_action"
+	ChargeStatusFailed         ChargeStatus = "failed"
+)
+
+// Charge is a payment taken from a saved payment method, without a checkout session
+type Charge struct {
+	ID     string       `json:"id"`
+	Status ChargeStatus `json:"status"`
+	Reason string       `json:"reason,omitempty"` // why the charge didn't succeed
+}
@@ -0,0 +1,274 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+)
+
+// EntryKind tells which business event a journal entry records
+type EntryKind string
+
+const (
+	EntryKindFare            EntryKind = "fare"
+	EntryKindTip             EntryKind = "tip"
+	EntryKindCancellationFee EntryKind = "cancellation_fee"
+	EntryKindRefund          EntryKind = "refund"
+	EntryKindPayout          EntryKind = "payout"
+)
+
+// Ledger accounts. Every driver has their own account, see DriverAccount.
+const (
+	// AccountCash is the money collected from riders through the payment processor
+	AccountCash = "platform:cash"
+	// AccountCommission is the platform's share of fares and cancellation fees
+	AccountCommission = "platform:commission"
+	// AccountPayouts is the money on its way to the drivers' bank accounts
+	AccountPayouts = "platform:payouts"
+
+	driverAccountPrefix = "driver:"
+)
+
+// DriverAccount is what the platform owes the driver, credited with their earnings and debited by payouts
+func DriverAccount(driverID string) string {
+	return driverAccountPrefix + driverID
+}
+
+// DriverIDFromAccount returns the driver of a driver account
+func DriverIDFromAccount(account string) (string, bool) {
+	return strings.CutPrefix(account, driverAccountPrefix)
+}
+
+// LedgerLine moves money in or out of one account, exactly one of Debit and Credit is set.
+// Amounts are in the minor unit of the entry's currency.
+type LedgerLine struct {
+	Account string `json:"account" bson:"account"`
+	Debit   int64  `json:"debit,omitempty" bson:"debit"`
+	Credit  int64  `json:"credit,omitempty" bson:"credit"`
+}
+
+// JournalEntry is one balanced double-entry transaction: its debits always equal its credits.
+// The id is derived from what it records (payment, refund, payout), so the same event is never posted twice.
+type JournalEntry struct {
+	ID        string       `json:"id" bson:"_id"`
+	Kind      EntryKind    `json:"kind" bson:"kind"`
+	Currency  string       `json:"currency" bson:"currency"`
+	TripID    string       `json:"trip_id,omitempty" bson:"tripId,omitempty"`
+	PaymentID string       `json:"payment_id,omitempty" bson:"paymentId,omitempty"`
+	DriverID  string       `json:"driver_id" bson:"driverId"`
+	Lines     []LedgerLine `json:"lines" bson:"lines"`
+	CreatedAt time.Time    `json:"created_at" bson:"createdAt"`
+}
+
+// Validate checks the entry balances and every line moves a positive amount one way, see NonZeroLines
+func (e *JournalEntry) Validate() error {
+	if len(e.Lines) < 2 {
+		return fmt.Errorf("journal entry %s needs at least two lines", e.ID)
+	}
+
+	var debits, credits int64
+	for _, line := range e.Lines {
+		if line.Debit < 0 || line.Credit < 0 || (line.Debit == 0) == (line.Credit == 0) {
+			return fmt.Errorf("journal entry %s: line on %s must either debit or credit a positive amount", e.ID, line.Account)
+		}
+		debits += line.Debit
+		credits += line.Credit
+	}
+
+	if debits != credits {
+		return fmt.Errorf("journal entry %s is unbalanced: %d debited, %d credited", e.ID, debits, credits)
+	}
+	return nil
+}
+
+// NonZeroLines leaves out the lines that move nothing, e.g. the driver's share of a fare the platform
+// keeps in full, or a share that rounds to 0 on a refund
+func NonZeroLines(lines ...LedgerLine) []LedgerLine {
+	kept := make([]LedgerLine, 0, len(lines))
+	for _, line := range lines {
+		if line.Debit != 0 || line.Credit != 0 {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+// Credited returns the amount credited to the account by this entry
+func (e *JournalEntry) Credited(account string) int64 {
+	var total int64
+	for _, line := range e.Lines {
+		if line.Account == account {
+			total += line.Credit
+		}
+	}
+	return total
+}
+
+// Debited returns the amount debited from the account by this entry
+func (e *JournalEntry) Debited(account string) int64 {
+	var total int64
+	for _, line := range e.Lines {
+		if line.Account == account {
+			total += line.Debit
+		}
+	}
+	return total
+}
+
+// DriverBalance is what the platform owes a driver in one currency
+type DriverBalance struct {
+	DriverID string            `json:"driver_id" bson:"driverId"`
+	Balance  sharedTypes.Money `json:"balance" bson:"balance"`
+}
+
+type PayoutStatus string
+
+const (
+	// PayoutStatusPending payouts are recorded in the ledger and wait to be transferred
+	PayoutStatusPending PayoutStatus = "pending"
+)
+
+// Payout transfers a driver's balance to them, payouts created by the same run share a batch
+type Payout struct {
+	ID        string            `json:"id" bson:"_id"`
+	BatchID   string            `json:"batch_id" bson:"batchId"`
+	DriverID  string            `json:"driver_id" bson:"driverId"`
+	Amount    sharedTypes.Money `json:"amount" bson:"amount"`
+	Status    PayoutStatus      `json:"status" bson:"status"`
+	CreatedAt time.Time         `json:"created_at" bson:"createdAt"`
+}
+
+// EarningsPeriod is the length of the periods earnings are grouped by
+type EarningsPeriod string
+
+const (
+	EarningsPeriodDay  EarningsPeriod = "day"
+	EarningsPeriodWeek EarningsPeriod = "week"
+)
+
+// Start returns the beginning of the period t falls in, in UTC. Weeks start on Monday.
+func (p EarningsPeriod) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if p != EarningsPeriodWeek {
+		return day
+	}
+
+	daysSinceMonday := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -daysSinceMonday)
+}
+
+// EarningsTotals sums a driver's ledger entries, all in the same currency
+type EarningsTotals struct {
+	Fares            sharedTypes.Money `json:"fares"` // the driver's share, after commission
+	Tips             sharedTypes.Money `json:"tips"`
+	CancellationFees sharedTypes.Money `json:"cancellation_fees"` // the driver's share, after commission
+	Refunds          sharedTypes.Money `json:"refunds"`           // taken back from the driver because of refunds
+	Commission       sharedTypes.Money `json:"commission"`        // kept by the platform, net of refunds
+	PaidOut          sharedTypes.Money `json:"paid_out"`
+}
+
+func NewEarningsTotals(currency string) *EarningsTotals {
+	zero := sharedTypes.NewMoney(0, currency)
+	return &EarningsTotals{
+		Fares:            zero,
+		Tips:             zero,
+		CancellationFees: zero,
+		Refunds:          zero,
+		Commission:       zero,
+		PaidOut:          zero,
+	}
+}
+
+// Net is what the driver earned: fares, tips and cancellation fees minus refunds
+func (t *EarningsTotals) Net() sharedTypes.Money {
+	return sharedTypes.NewMoney(t.Fares.Amount+t.Tips.Amount+t.CancellationFees.Amount-t.Refunds.Amount, t.Fares.Currency)
+}
+
+// Add accounts for the entry from the point of view of the driver
+func (t *EarningsTotals) Add(entry *JournalEntry) {
+	driverAccount := DriverAccount(entry.DriverID)
+
+	switch entry.Kind {
+	case EntryKindFare:
+		t.Fares.Amount += entry.Credited(driverAccount)
+	case EntryKindTip:
+		t.Tips.Amount += entry.Credited(driverAccount)
+	case EntryKindCancellationFee:
+		t.CancellationFees.Amount += entry.Credited(driverAccount)
+	case EntryKindRefund:
+		t.Refunds.Amount += entry.Debited(driverAccount)
+	case EntryKindPayout:
+		t.PaidOut.Amount += entry.Debited(driverAccount)
+	}
+
+	t.Commission.Amount += entry.Credited(AccountCommission) - entry.Debited(AccountCommission)
+}
+
+type PeriodEarnings struct {
+	Start  time.Time       `json:"start"`
+	Totals *EarningsTotals `json:"totals"`
+}
+
+type TripEarnings struct {
+	TripID string          `json:"trip_id"`
+	Totals *EarningsTotals `json:"totals"`
+}
+
+// DriverEarnings is what a driver earned over a time range, in one currency
+type DriverEarnings struct {
+	DriverID string            `json:"driver_id"`
+	Currency string            `json:"currency"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Totals   *EarningsTotals   `json:"totals"`
+	Periods  []*PeriodEarnings `json:"periods"` // oldest first, periods without entries are left out
+	Trips    []*TripEarnings   `json:"trips"`
+	// Balance is everything earned and not paid out yet, regardless of the time range
+	Balance sharedTypes.Money `json:"balance"`
+}
+
+// EarningsConfig sets how fares are split between the platform and the drivers
+type EarningsConfig struct {
+	// DefaultCommissionPercent applies to the car packages without their own rate
+	DefaultCommissionPercent float64
+	CommissionPercent        map[string]float64
+}
+
+func (c *EarningsConfig) CommissionPercentFor(packageSlug string) float64 {
+	if percent, ok := c.CommissionPercent[packageSlug]; ok {
+		return percent
+	}
+	return c.DefaultCommissionPercent
+}
+
+// ValidCommissionPercent reports whether the commission is a percentage between 0 and 100
+func ValidCommissionPercent(percent float64) bool {
+	return percent >= 0 && percent <= 100
+}
+
+// ParseCommissionRates parses per package commission rates in percent, e.g. "luxury=25,van=18"
+func ParseCommissionRates(rates string) (map[string]float64, error) {
+	parsed := make(map[string]float64)
+	if strings.TrimSpace(rates) == "" {
+		return parsed, nil
+	}
+
+	for _, pair := range strings.Split(rates, ",") {
+		packageSlug, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid commission rate %q, expected package=percent", pair)
+		}
+
+		percent, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || !ValidCommissionPercent(percent) {
+			return nil, fmt.Errorf("invalid commission rate %q, expected a percentage between 0 and 100", pair)
+		}
+
+		parsed[strings.TrimSpace(packageSlug)] = percent
+	}
+
+	return parsed, nil
+}
@@ -1,6 +1,10 @@
 package types
 
-import "time"
+import (
+	"time"
+
+	sharedTypes "github.com/tenteedee/mini-uber/shared/types"
+)
 
 // PaymentStatus represents the current status of a payment
 type PaymentStatus string
@@ -12,29 +16,112 @@ const (
 	PaymentStatusCancelled PaymentStatus = "cancelled"
 )
 
+// CanTransitionTo reports whether a payment in this status may move to next.
+// A failed payment can still succeed or expire when the rider retries on the same checkout session.
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	switch s {
+	case PaymentStatusPending:
+		return next != PaymentStatusPending
+	case PaymentStatusFailed:
+		return next == PaymentStatusSuccess || next == PaymentStatusCancelled
+	default:
+		return false
+	}
+}
+
+// PaymentKind tells what a payment is for, payments recorded before tips existed have no kind and are fares
+type PaymentKind string
+
+const (
+	PaymentKindFare PaymentKind = "fare"
+	PaymentKindTip  PaymentKind = "tip"
+	// PaymentKindCancellationFee is charged to a rider cancelling after a driver accepted, it is split like a fare
+	PaymentKindCancellationFee PaymentKind = "cancellation_fee"
+)
+
 // Payment represents a payment transaction
 type Payment struct {
-	ID              string        `json:"id"`
-	TripID          string        `json:"trip_id"`
-	UserID          string        `json:"user_id"`
-	Amount          int64         `json:"amount"` // price in cents
-	Currency        string        `json:"currency"`
-	Status          PaymentStatus `json:"status"`
-	StripeSessionID string        `json:"stripe_session_id"`
-	CreatedAt       time.Time     `json:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at"`
+	ID              string            `json:"id" bson:"_id"` // fare:<trip id> for fares and the tip id for tips, so each is charged once
+	Kind            PaymentKind       `json:"kind,omitempty" bson:"kind,omitempty"`
+	TripID          string            `json:"trip_id" bson:"tripId"`
+	UserID          string            `json:"user_id" bson:"userId"`
+	DriverID        string            `json:"driver_id" bson:"driverId"`
+	PackageSlug     string            `json:"package_slug,omitempty" bson:"packageSlug,omitempty"` // car package of the trip, sets the commission
+	Amount          sharedTypes.Money `json:"amount" bson:"amount"`
+	Status          PaymentStatus     `json:"status" bson:"status"`
+	StripeSessionID string            `json:"stripe_session_id" bson:"stripeSessionId"` // or the processor's charge, for saved payment methods
+	CheckoutURL     string            `json:"checkout_url,omitempty" bson:"checkoutUrl,omitempty"`
+	// PaymentMethodID is the saved payment method the fare is charged to when the trip completes
+	PaymentMethodID string            `json:"payment_method_id,omitempty" bson:"paymentMethodId,omitempty"`
+	FailureReason   string            `json:"failure_reason,omitempty" bson:"failureReason,omitempty"`
+	RefundedAmount  sharedTypes.Money `json:"refunded_amount" bson:"refundedAmount"` // never more than Amount
+	CreatedAt       time.Time         `json:"created_at" bson:"createdAt"`
+	UpdatedAt       time.Time         `json:"updated_at" bson:"updatedAt"`
+}
+
+// Refund gives back part or all of a successful payment
+type Refund struct {
+	ID                string            `json:"id" bson:"_id"`
+	PaymentID         string            `json:"payment_id" bson:"paymentId"`
+	TripID            string            `json:"trip_id" bson:"tripId"`
+	Amount            sharedTypes.Money `json:"amount" bson:"amount"`
+	Reason            string            `json:"reason" bson:"reason"`
+	ProcessorRefundID string            `json:"processor_refund_id" bson:"processorRefundId"`
+	CreatedAt         time.Time         `json:"created_at" bson:"createdAt"`
+}
+
+// Scheduled reports whether the payment waits for the trip to complete to be charged to a saved payment method
+func (p *Payment) Scheduled() bool {
+	return p.Status == PaymentStatusPending && p.PaymentMethodID != "" && p.StripeSessionID == ""
+}
+
+// FellBackToCheckout reports whether the payment was scheduled on a saved payment method that couldn't be
+// charged, and waits for the rider to pay on a checkout session instead
+func (p *Payment) FellBackToCheckout() bool {
+	return p.Status == PaymentStatusPending && p.PaymentMethodID != "" && p.CheckoutURL != ""
 }
 
 // PaymentIntent represents the intent to collect a payment
 type PaymentIntent struct {
-	ID              string    `json:"id"`
-	TripID          string    `json:"trip_id"`
-	UserID          string    `json:"user_id"`
-	DriverID        string    `json:"driver_id"`
-	Amount          int64     `json:"amount"`
-	Currency        string    `json:"currency"`
-	StripeSessionID string    `json:"stripe_session_id"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID              string            `json:"id"`
+	TripID          string            `json:"trip_id"`
+	UserID          string            `json:"user_id"`
+	DriverID        string            `json:"driver_id"`
+	Amount          sharedTypes.Money `json:"amount"`
+	StripeSessionID string            `json:"stripe_session_id"`
+	CheckoutURL     string            `json:"checkout_url,omitempty"`
+	// PaymentMethod is set instead of the session when the payment is charged to a saved payment method
+	PaymentMethod *PaymentMethod `json:"payment_method,omitempty"`
+	// FallbackReason tells why a saved payment method couldn't be charged and the rider has to check out
+	FallbackReason string    `json:"fallback_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CheckoutSession is a session created on the payment processor, the rider pays on its hosted checkout page
+type CheckoutSession struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// PaymentEventType is the processor independent outcome of a payment, as reported by the processor webhooks
+type PaymentEventType string
+
+const (
+	PaymentEventSucceeded PaymentEventType = "succeeded"
+	PaymentEventFailed    PaymentEventType = "failed"
+	PaymentEventCancelled PaymentEventType = "cancelled"
+	// PaymentEventMethodSaved reports a payment method saved by the rider on a setup session
+	PaymentEventMethodSaved PaymentEventType = "payment_method_saved"
+)
+
+// PaymentEvent is a verified webhook notification translated from the processor's own format
+type PaymentEvent struct {
+	Type      PaymentEventType  `json:"type"`
+	SessionID string            `json:"session_id"`
+	Metadata  map[string]string `json:"metadata"` // payment_id, trip_id, user_id and driver_id set when the session was created
+	Reason    string            `json:"reason,omitempty"`
+	// PaymentMethod is the method saved by a PaymentEventMethodSaved event
+	PaymentMethod *PaymentMethod `json:"payment_method,omitempty"`
 }
 
 // PaymentConfig holds the configuration for the payment service
@@ -45,4 +132,7 @@ type PaymentConfig struct {
 	Currency            string `json:"currency"`
 	SuccessURL          string `json:"successURL"`
 	CancelURL           string `json:"cancelURL"`
+	// the rider comes back to these after saving a payment method, or giving up on it
+	SetupSuccessURL string `json:"setupSuccessURL"`
+	SetupCancelURL  string `json:"setupCancelURL"`
 }
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/tenteedee/mini-uber/services/trip-service/internal/infrastructure/events"
 	"github.com/tenteedee/mini-uber/services/trip-service/internal/infrastructure/grpc"
@@ -15,8 +16,11 @@ import (
 	"github.com/tenteedee/mini-uber/shared/db"
 	"github.com/tenteedee/mini-uber/shared/env"
 	"github.com/tenteedee/mini-uber/shared/messaging"
+	pb "github.com/tenteedee/mini-uber/shared/proto/trip"
 	"github.com/tenteedee/mini-uber/shared/tracing"
 	grpcserver "google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 var GrpcAddr = ":9093"
@@ -67,24 +71,50 @@ func main() {
 	}
 
 	// Initialize RabbitMQ connection
-	rabbitmq, err := messaging.NewRabbitMQ(rabbitmqURI)
+	rabbitmq, err := messaging.NewRabbitMQ(rabbitmqURI, tracerCfg.ServiceName)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer rabbitmq.Close()
 	log.Println("starting RabbitMQ connection on Trip service")
 
+	// consumers skip the messages delivered again once they handled them
+	dedupStore, err := messaging.NewMongoDedupStore(ctx, mongoDb)
+	if err != nil {
+		log.Fatalf("Failed to initialize the message dedup store, err: %v", err)
+	}
+
 	// Initialize TripEventPublisher
 	publisher := events.NewTripEventPublisher(rabbitmq)
 
 	// Initialize and start DriverEventConsumer
-	consumer := events.NewTripEventConsumer(rabbitmq, tripService)
+	consumer := events.NewTripEventConsumer(rabbitmq, tripService, dedupStore)
 	go consumer.Listen()
 
+	// Track which driver each trip request was offered to
+	offerConsumer := events.NewDriverOfferConsumer(rabbitmq, tripService, dedupStore)
+	go offerConsumer.Listen()
+
+	// Initialize and start PaymentConsumer
+	paymentConsumer := events.NewPaymentConsumer(rabbitmq, tripService, dedupStore)
+	go paymentConsumer.Listen()
+
 	// Initialize and start gRPC server
 	grpcServer := grpcserver.NewServer()
 	grpc.NewgRPCHandler(grpcServer, tripService, publisher)
 
+	// report serving status so gRPC clients only balance over healthy replicas
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	// and not while RabbitMQ is reconnecting, the events of their calls could not be published
+	rabbitmq.OnStateChange(func(state messaging.ConnectionState) {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if state == messaging.StateConnected {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		healthServer.SetServingStatus(pb.TripService_ServiceDesc.ServiceName, status)
+	})
+
 	log.Printf("starting Trip gRPC server on %s", listener.Addr().String())
 
 	// Start gRPC server in a separate goroutine
@@ -97,6 +127,14 @@ func main() {
 
 	<-ctx.Done()
 	log.Println("shutting down Trip gRPC server")
+	// stop routing new calls to this replica before draining the in-flight ones
+	healthServer.Shutdown()
 	grpcServer.GracefulStop()
 
+	// let the consumers finish the messages they received before the connection closes
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer drainCancel()
+	if err := rabbitmq.Shutdown(drainCtx); err != nil {
+		log.Printf("could not drain RabbitMQ consumers: %v", err)
+	}
 }
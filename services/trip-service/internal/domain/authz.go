@@ -0,0 +1,66 @@
+package domain
+
+// Role identifies on whose behalf an action on a trip is performed.
+type Role string
+
+const (
+	RoleRider  Role = "rider"
+	RoleDriver Role = "driver"
+)
+
+// Actor is the identity performing an action, as forwarded by the api-gateway.
+type Actor struct {
+	ID   string
+	Role Role
+}
+
+// CanAct reports whether the actor is allowed to act on the trip.
+// Riders may only act on trips they own, drivers only on a pending trip
+// that is currently offered to them. A pending trip offered to no one may not
+// have the offer to the actor recorded yet, that is ErrNoOutstandingOffer. The
+// offer of a driver who declined is withdrawn, so a trip offered to another
+// driver is not the actor's to act on.
+func (t *TripModel) CanAct(actor Actor) error {
+	if actor.ID == "" {
+		return ErrPermissionDenied
+	}
+
+	switch actor.Role {
+	case RoleRider:
+		if t.UserID != actor.ID {
+			return ErrPermissionDenied
+		}
+		return nil
+	case RoleDriver:
+		if t.Status != "pending" {
+			return ErrPermissionDenied
+		}
+		if t.OfferedDriverID == "" {
+			return ErrNoOutstandingOffer
+		}
+		if t.OfferedDriverID != actor.ID {
+			return ErrPermissionDenied
+		}
+		return nil
+	}
+
+	return ErrPermissionDenied
+}
+
+// IsAcceptedBy reports whether the driver accepted the trip and the rider didn't pay yet.
+func (t *TripModel) IsAcceptedBy(driverID string) bool {
+	return t.Status == "accepted" && t.Driver != nil && t.Driver.Id == driverID
+}
+
+// CanComplete reports whether the actor may complete the trip: only the assigned
+// driver, once the rider has paid or the fare is scheduled on their saved payment method.
+func (t *TripModel) CanComplete(actor Actor) error {
+	if actor.ID == "" || actor.Role != RoleDriver || t.Driver == nil || t.Driver.Id != actor.ID {
+		return ErrPermissionDenied
+	}
+
+	if t.Status != "payed" && t.Status != "payment_scheduled" {
+		return ErrTripNotPaid
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTripNotFound       = errors.New("trip not found")
+	ErrFareNotFound       = errors.New("ride fare not found")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrFareNotOwned       = fmt.Errorf("%w: fare does not belong to user", ErrPermissionDenied)
+	ErrNoOutstandingOffer = errors.New("trip has no outstanding driver offer")
+	ErrTripNotPaid        = errors.New("trip is not paid")
+	ErrTripNotCompleted   = errors.New("trip is not completed")
+	ErrInvalidTipAmount   = errors.New("invalid tip amount")
+	ErrTipAlreadyGiven    = errors.New("trip already has a tip")
+
+	// ErrRouteNotFound is returned when the routing engine cannot find a route between the points,
+	// ErrRoutingUnavailable when the routing engine itself could not be reached.
+	ErrRouteNotFound      = errors.New("no route found between pickup and destination")
+	ErrRoutingUnavailable = errors.New("routing service unavailable")
+)
@@ -3,23 +3,48 @@ package domain
 import (
 	tripTypes "github.com/tenteedee/mini-uber/services/trip-service/pkg/types"
 	pb "github.com/tenteedee/mini-uber/shared/proto/trip"
+	"github.com/tenteedee/mini-uber/shared/types"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type RideFareModel struct {
-	ID                primitive.ObjectID         `bson:"_id,omitempty"`
-	UserID            string                     `bson:"userId"`
-	PackageSlug       string                     `bson:"packageSlug"` // van, suv, sedan
-	TotalPriceInCents float64                    `bson:"totalPriceInCents"`
-	Route             *tripTypes.OsrmApiResponse `bson:"route"`
+	ID          primitive.ObjectID         `bson:"_id,omitempty"`
+	UserID      string                     `bson:"userId"`
+	PackageSlug string                     `bson:"packageSlug"` // van, suv, sedan
+	Price       types.Money                `bson:"price"`
+	Route       *tripTypes.OsrmApiResponse `bson:"route"`
+}
+
+// UnmarshalBSON also reads the fares stored before the price was Money, in ride_fares and in
+// the trips, they hold the price as US cents in totalPriceInCents
+func (r *RideFareModel) UnmarshalBSON(data []byte) error {
+	type rideFareModel RideFareModel
+	if err := bson.Unmarshal(data, (*rideFareModel)(r)); err != nil {
+		return err
+	}
+	if r.Price.Currency != "" {
+		return nil
+	}
+
+	var legacy struct {
+		TotalPriceInCents *float64 `bson:"totalPriceInCents"`
+	}
+	if err := bson.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	if legacy.TotalPriceInCents != nil {
+		r.Price = types.RoundMoney(*legacy.TotalPriceInCents, types.DefaultCurrency)
+	}
+	return nil
 }
 
 func (r *RideFareModel) ToProto() *pb.Ridefare {
 	return &pb.Ridefare{
-		Id:                r.ID.Hex(),
-		UserID:            r.UserID,
-		PackageSlug:       r.PackageSlug,
-		TotalPriceInCents: r.TotalPriceInCents,
+		Id:          r.ID.Hex(),
+		UserID:      r.UserID,
+		PackageSlug: r.PackageSlug,
+		Price:       r.Price.ToProto(),
 	}
 }
 
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	pbd "github.com/tenteedee/mini-uber/shared/proto/driver"
 	pb "github.com/tenteedee/mini-uber/shared/proto/trip"
@@ -12,11 +14,60 @@ import (
 )
 
 type TripModel struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty"`
-	UserID   string             `bson:"userId"`
-	Status   string             `bson:"status"`
-	RideFare *RideFareModel     `bson:"rideFare"`
-	Driver   *pb.TripDriver     `bson:"driver"`
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	UserID          string             `bson:"userId"`
+	Status          string             `bson:"status"`
+	RideFare        *RideFareModel     `bson:"rideFare"`
+	Driver          *pb.TripDriver     `bson:"driver"`
+	OfferedDriverID string             `bson:"offeredDriverId,omitempty"` // driver currently holding the trip request
+	Refunds         []*TripRefund      `bson:"refunds,omitempty"`
+	Tip             *TripTip           `bson:"tip,omitempty"`
+}
+
+// Tip statuses, a failed tip can be replaced by a new one
+const (
+	TipStatusPending  = "pending"
+	TipStatusReceived = "received"
+	TipStatusFailed   = "failed"
+)
+
+// TripTip is the tip the rider gave the driver once the trip was completed,
+// it is charged separately from the fare
+type TripTip struct {
+	TipID         string      `bson:"tipId"`
+	Amount        types.Money `bson:"amount"`
+	Status        string      `bson:"status"`
+	FailureReason string      `bson:"failureReason,omitempty"`
+	CreatedAt     time.Time   `bson:"createdAt"`
+}
+
+// TripRefund records money given back to the rider for this trip
+type TripRefund struct {
+	RefundID  string      `bson:"refundId"`
+	PaymentID string      `bson:"paymentId"`
+	Amount    types.Money `bson:"amount"`
+	Reason    string      `bson:"reason"`
+	CreatedAt time.Time   `bson:"createdAt"`
+}
+
+// ValidateTip checks that the tip is in the currency of the fare and at most the fare itself
+func (t *TripModel) ValidateTip(amount types.Money) error {
+	if amount.Amount <= 0 {
+		return fmt.Errorf("%w: %s is not positive", ErrInvalidTipAmount, amount)
+	}
+
+	if t.RideFare == nil {
+		return nil
+	}
+
+	fare := t.RideFare.Price
+	if amount.Currency != fare.Currency {
+		return fmt.Errorf("%w: tip in %s on a fare in %s", ErrInvalidTipAmount, amount.Currency, fare.Currency)
+	}
+	if amount.Amount > fare.Amount {
+		return fmt.Errorf("%w: %s is more than the fare of %s", ErrInvalidTipAmount, amount, fare)
+	}
+	return nil
 }
 
 func (t *TripModel) ToProto() *pb.Trip {
@@ -36,14 +87,37 @@ type TripRepository interface {
 	GetRideFareByID(ctx context.Context, fareID string) (*RideFareModel, error)
 	GetTripByID(ctx context.Context, id string) (*TripModel, error)
 	UpdateTrip(ctx context.Context, tripID string, status string, driver *pbd.Driver) error
+	SetOfferedDriver(ctx context.Context, tripID string, driverID string) error
+	// ClearOfferedDriver withdraws the offer from the driver, an offer made to another driver meanwhile is kept
+	ClearOfferedDriver(ctx context.Context, tripID string, driverID string) error
+	// AddRefund appends the refund to the trip, a refund that is already recorded is ignored
+	AddRefund(ctx context.Context, tripID string, refund *TripRefund) error
+	// SetTip records the tip on a completed trip, it returns ErrTipAlreadyGiven when the trip
+	// already has a tip that did not fail
+	SetTip(ctx context.Context, tripID string, tip *TripTip) error
+	// UpdateTipStatus moves the tip to the status, a tip that was replaced in the meantime is left alone
+	UpdateTipStatus(ctx context.Context, tripID string, tipID string, status string, reason string) error
 }
 
 type TripService interface {
 	CreateTrip(ctx context.Context, fare *RideFareModel) (*TripModel, error)
 	GetTripRoute(ctx context.Context, pickup *types.Coordinate, destination *types.Coordinate, useOSRMApi bool) (*tripTypes.OsrmApiResponse, error)
-	EstimatePackagesPriceWithRoutes(route *tripTypes.OsrmApiResponse) []*RideFareModel
+	EstimatePackagesPriceWithRoutes(pickup *types.Coordinate, route *tripTypes.OsrmApiResponse) []*RideFareModel
 	GenerateTripFares(ctx context.Context, fares []*RideFareModel, userId string, route *tripTypes.OsrmApiResponse) ([]*RideFareModel, error)
 	GetAndValidateFare(ctx context.Context, fareID string, userID string) (*RideFareModel, error)
 	GetTripById(ctx context.Context, tripId string) (*TripModel, error)
 	UpdateTrip(ctx context.Context, tripId string, status string, driver *pbd.Driver) error
+	RecordDriverOffer(ctx context.Context, tripId string, driverId string) error
+	// WithdrawDriverOffer clears the offer of a driver who declined the trip
+	WithdrawDriverOffer(ctx context.Context, tripId string, driverId string) error
+	AuthorizeTripAction(ctx context.Context, tripId string, actor Actor) (*TripModel, error)
+	RecordRefund(ctx context.Context, tripId string, refund *TripRefund) error
+	// CompleteTrip marks a paid trip as completed by its driver
+	CompleteTrip(ctx context.Context, tripId string, actor Actor) (*TripModel, error)
+	// TipTrip records the rider's tip on a completed trip, the tip still has to be charged
+	TipTrip(ctx context.Context, tripId string, actor Actor, amount types.Money) (*TripModel, *TripTip, error)
+	RecordTipOutcome(ctx context.Context, tripId string, tipId string, status string, reason string) error
+	// RecordPaymentOutcome sets the payment status of the trip. Fares charged to a saved payment method
+	// settle once the trip is completed, which then stays completed.
+	RecordPaymentOutcome(ctx context.Context, tripId string, status string) error
 }
@@ -2,10 +2,9 @@ package events
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"log"
 
-	"github.com/rabbitmq/amqp091-go"
 	"github.com/tenteedee/mini-uber/services/trip-service/internal/domain"
 	"github.com/tenteedee/mini-uber/shared/contracts"
 	"github.com/tenteedee/mini-uber/shared/messaging"
@@ -15,138 +14,152 @@ import (
 type DriverEventConsumer struct {
 	rabbitmq *messaging.RabbitMQ
 	service  domain.TripService
+	dedup    messaging.DedupStore
 }
 
-func NewTripEventConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService) *DriverEventConsumer {
+func NewTripEventConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService, dedup messaging.DedupStore) *DriverEventConsumer {
 	return &DriverEventConsumer{
 		rabbitmq: rabbitmq,
 		service:  service,
+		dedup:    dedup,
 	}
 }
 
 func (c *DriverEventConsumer) Listen() error {
+	subscriptions := messaging.NewSubscriptions()
+	messaging.Subscribe(subscriptions, messaging.DriverCmdTripAccept, c.handleDriverResponse)
+	messaging.Subscribe(subscriptions, messaging.DriverCmdTripDecline, c.handleDriverResponse)
+	messaging.Subscribe(subscriptions, messaging.DriverCmdTripComplete, c.handleTripCompleted)
+
 	return c.rabbitmq.ConsumeMessages(
 		messaging.DriverTripResponseQueue,
-		func(ctx context.Context, msg amqp091.Delivery) error {
-			var message contracts.AmqpMessage
+		messaging.DefaultConsumerConfig(),
+		messaging.Deduplicate(c.dedup, messaging.DriverTripResponseQueue, subscriptions.Handle),
+	)
+}
 
-			if err := json.Unmarshal(msg.Body, &message); err != nil {
-				log.Printf("failed to unmarshal trip event message: %v", err)
-				return err
-			}
+// handleDriverResponse accepts or declines the trip on behalf of the driver it was offered to
+func (c *DriverEventConsumer) handleDriverResponse(ctx context.Context, msg messaging.Message[messaging.DriverTripResponseData]) error {
+	payload := msg.Data
+	log.Printf("driver response received message: %+v", payload)
 
-			var payload messaging.DriverTripResponseData
-			if err := json.Unmarshal(message.Data, &payload); err != nil {
-				log.Printf("failed to unmarshal trip event data: %v", err)
-				return err
-			}
+	// the owner of a driver command is the driver identity the api-gateway
+	// attached to the websocket connection, not whatever the client put in the payload
+	actor := domain.Actor{ID: msg.OwnerID, Role: domain.RoleDriver}
+	if payload.Driver == nil || payload.Driver.Id != actor.ID {
+		log.Printf("rejecting %s for trip %s: driver in payload does not match sender %s", msg.RoutingKey, payload.TripId, actor.ID)
+		return nil
+	}
 
-			log.Printf("driver response received message: %+v", payload)
-
-			switch msg.RoutingKey {
-			case contracts.DriverCmdTripAccept:
-				if err := c.handleTripAccepted(ctx, payload.TripId, payload.Driver); err != nil {
-					log.Printf("failed to handle trip accept: %v", err)
-					return err
-				}
-			case contracts.DriverCmdTripDecline:
-				if err := c.handleTripDeclined(ctx, payload.TripId, payload.RiderId); err != nil {
-					log.Printf("Failed to handle the trip decline: %v", err)
-					return err
-				}
-				return nil
+	trip, err := c.service.AuthorizeTripAction(ctx, payload.TripId, actor)
+	if err != nil {
+		if errors.Is(err, domain.ErrPermissionDenied) && msg.RoutingKey == contracts.DriverCmdTripAccept {
+			// the trip is no longer pending once accepted, a retry of the accept only publishes what it failed to
+			if accepted, getErr := c.service.GetTripById(ctx, payload.TripId); getErr == nil && accepted.IsAcceptedBy(actor.ID) {
+				return c.publishTripAccepted(ctx, accepted, payload.Driver)
 			}
-			log.Printf("unknown trip event: %+v", payload)
-
+		}
+		if errors.Is(err, domain.ErrNoOutstandingOffer) {
+			// the offer may not have been recorded yet, let the consumer retry
+			return err
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) || errors.Is(err, domain.ErrTripNotFound) {
+			log.Printf("rejecting %s: %v", msg.RoutingKey, err)
 			return nil
-		})
-}
-
-func (c *DriverEventConsumer) handleTripAccepted(ctx context.Context, tripId string, driver *pbd.Driver) error {
-	trip, err := c.service.GetTripById(ctx, tripId)
-	if err != nil {
+		}
 		return err
 	}
 
-	if trip == nil {
-		log.Printf("trip not found: %s", tripId)
+	if msg.RoutingKey == contracts.DriverCmdTripDecline {
+		if err := c.handleTripDeclined(ctx, trip, actor.ID); err != nil {
+			log.Printf("Failed to handle the trip decline: %v", err)
+			return err
+		}
 		return nil
 	}
 
-	if err := c.service.UpdateTrip(ctx, tripId, "accepted", driver); err != nil {
-		log.Printf("failed to update trip: %v", err)
+	if err := c.handleTripAccepted(ctx, trip, payload.Driver); err != nil {
+		log.Printf("failed to handle trip accept: %v", err)
 		return err
 	}
+	return nil
+}
 
-	trip, err = c.service.GetTripById(ctx, tripId)
-	if err != nil {
+func (c *DriverEventConsumer) handleTripAccepted(ctx context.Context, trip *domain.TripModel, driver *pbd.Driver) error {
+	tripId := trip.ID.Hex()
+
+	if err := c.service.UpdateTrip(ctx, tripId, "accepted", driver); err != nil {
+		log.Printf("failed to update trip: %v", err)
 		return err
 	}
 
-	mashhalledTrip, err := json.Marshal(trip)
+	trip, err := c.service.GetTripById(ctx, tripId)
 	if err != nil {
-		log.Printf("failed to marshal trip: %v", err)
 		return err
 	}
 
+	return c.publishTripAccepted(ctx, trip, driver)
+}
+
+// publishTripAccepted tells the rider about the driver and asks for the payment of the fare, the payment
+// service opens a single session per trip however many times it is asked
+func (c *DriverEventConsumer) publishTripAccepted(ctx context.Context, trip *domain.TripModel, driver *pbd.Driver) error {
+	tripId := trip.ID.Hex()
+
 	// notify the rider that the driver has been assigned
-	if err := c.rabbitmq.PublishMessage(
-		ctx,
-		contracts.TripEventDriverAssigned,
-		contracts.AmqpMessage{
-			OwnerID: trip.UserID,
-			Data:    mashhalledTrip,
-		},
-	); err != nil {
+	if err := messaging.Publish(ctx, c.rabbitmq, messaging.TripEventDriverAssigned, trip.UserID, trip.ToProto()); err != nil {
 		log.Printf("failed to publish trip driver assigned event: %v", err)
 		return err
 	}
 
 	// notify the payment service to start a payment link
-	marshalledPayload, err := json.Marshal(messaging.PaymentTripResponseData{
-		TripID:   tripId,
-		UserID:   trip.UserID,
-		DriverID: driver.Id,
-		Amount:   trip.RideFare.TotalPriceInCents,
-		Currency: "USD",
-	})
-
-	if err := c.rabbitmq.PublishMessage(ctx, contracts.PaymentCmdCreateSession,
-		contracts.AmqpMessage{
-			OwnerID: trip.UserID,
-			Data:    marshalledPayload,
-		},
-	); err != nil {
+	if err := messaging.Publish(ctx, c.rabbitmq, messaging.PaymentCmdCreateSession, trip.UserID, messaging.PaymentTripResponseData{
+		TripID:      tripId,
+		UserID:      trip.UserID,
+		DriverID:    driver.Id,
+		PackageSlug: trip.RideFare.PackageSlug,
+		Amount:      trip.RideFare.Price,
+	}); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (c *DriverEventConsumer) handleTripDeclined(ctx context.Context, tripID string, riderID string) error {
+func (c *DriverEventConsumer) handleTripDeclined(ctx context.Context, trip *domain.TripModel, driverId string) error {
 	// When a driver declines, we should try to find another driver
 
-	trip, err := c.service.GetTripById(ctx, tripID)
-	if err != nil {
+	if err := messaging.Publish(ctx, c.rabbitmq, messaging.TripEventDriverNotInterested, trip.UserID, messaging.TripEventData{
+		Trip: trip.ToProto(),
+	}); err != nil {
 		return err
 	}
 
-	newPayload := messaging.TripEventData{
-		Trip: trip.ToProto(),
-	}
+	// withdrawn once published, a retry of the decline still finds the offer. The accept of the next
+	// driver waits for their offer rather than being denied against this one.
+	return c.service.WithdrawDriverOffer(ctx, trip.ID.Hex(), driverId)
+}
+
+func (c *DriverEventConsumer) handleTripCompleted(ctx context.Context, msg messaging.Message[messaging.DriverTripCompleteData]) error {
+	// as for the other driver commands, the driver is the identity the api-gateway attached to the connection
+	actor := domain.Actor{ID: msg.OwnerID, Role: domain.RoleDriver}
 
-	marshalledPayload, err := json.Marshal(newPayload)
+	trip, err := c.service.CompleteTrip(ctx, msg.Data.TripID, actor)
 	if err != nil {
+		if errors.Is(err, domain.ErrPermissionDenied) || errors.Is(err, domain.ErrTripNotFound) || errors.Is(err, domain.ErrTripNotPaid) {
+			log.Printf("rejecting %s: %v", contracts.DriverCmdTripComplete, err)
+			return nil
+		}
 		return err
 	}
 
-	if err := c.rabbitmq.PublishMessage(ctx, contracts.TripEventDriverNotInterested,
-		contracts.AmqpMessage{
-			OwnerID: riderID,
-			Data:    marshalledPayload,
-		},
-	); err != nil {
+	// notify the rider, who can now tip the driver
+	if err := messaging.Publish(ctx, c.rabbitmq, messaging.TripEventCompleted, trip.UserID, messaging.TripEventData{
+		Trip: trip.ToProto(),
+	}); err != nil {
+		log.Printf("failed to publish trip completed event: %v", err)
 		return err
 	}
 
+	log.Printf("trip %s completed by driver %s", trip.ID.Hex(), actor.ID)
 	return nil
 }
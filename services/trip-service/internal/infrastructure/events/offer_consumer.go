@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"log"
+
+	"github.com/tenteedee/mini-uber/services/trip-service/internal/domain"
+	"github.com/tenteedee/mini-uber/shared/messaging"
+)
+
+// DriverOfferConsumer keeps track of which driver a trip request was sent to,
+// so that only that driver can later accept or decline it.
+type DriverOfferConsumer struct {
+	rabbitmq *messaging.RabbitMQ
+	service  domain.TripService
+	dedup    messaging.DedupStore
+}
+
+func NewDriverOfferConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService, dedup messaging.DedupStore) *DriverOfferConsumer {
+	return &DriverOfferConsumer{
+		rabbitmq: rabbitmq,
+		service:  service,
+		dedup:    dedup,
+	}
+}
+
+func (c *DriverOfferConsumer) Listen() error {
+	subscriptions := messaging.NewSubscriptions()
+	messaging.Subscribe(subscriptions, messaging.DriverCmdTripRequest, c.handleTripRequest)
+
+	return c.rabbitmq.ConsumeMessages(
+		messaging.TripDriverOfferQueue,
+		messaging.DefaultConsumerConfig(),
+		messaging.Deduplicate(c.dedup, messaging.TripDriverOfferQueue, subscriptions.Handle),
+	)
+}
+
+func (c *DriverOfferConsumer) handleTripRequest(ctx context.Context, msg messaging.Message[messaging.TripEventData]) error {
+	if msg.Data.Trip == nil || msg.OwnerID == "" {
+		log.Printf("ignoring malformed driver offer: %+v", msg.Data)
+		return nil
+	}
+
+	log.Printf("trip %s offered to driver %s", msg.Data.Trip.Id, msg.OwnerID)
+
+	return c.service.RecordDriverOffer(ctx, msg.Data.Trip.Id, msg.OwnerID)
+}
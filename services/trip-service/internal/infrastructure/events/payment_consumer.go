@@ -2,48 +2,95 @@ package events
 
 import (
 	"context"
-	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/tenteedee/mini-uber/services/trip-service/internal/domain"
 	"github.com/tenteedee/mini-uber/shared/contracts"
 	"github.com/tenteedee/mini-uber/shared/messaging"
-
-	"github.com/rabbitmq/amqp091-go"
 )
 
 type paymentConsumer struct {
 	rabbitmq *messaging.RabbitMQ
 	service  domain.TripService
+	dedup    messaging.DedupStore
 }
 
-func NewPaymentConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService) *paymentConsumer {
+func NewPaymentConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService, dedup messaging.DedupStore) *paymentConsumer {
 	return &paymentConsumer{
 		rabbitmq: rabbitmq,
 		service:  service,
+		dedup:    dedup,
 	}
 }
 
 func (c *paymentConsumer) Listen() error {
-	return c.rabbitmq.ConsumeMessages(messaging.NotifyPaymentSuccessQueue, func(ctx context.Context, msg amqp091.Delivery) error {
-		var message contracts.AmqpMessage
-		if err := json.Unmarshal(msg.Body, &message); err != nil {
-			log.Printf("Failed to unmarshal message: %v", err)
-			return err
-		}
-		var payload messaging.PaymentStatusUpdateData
-		if err := json.Unmarshal(message.Data, &payload); err != nil {
-			log.Printf("Failed to unmarshal payload: %v", err)
-			return err
-		}
-
-		log.Printf("Trip has been completed and payed.")
-
-		return c.service.UpdateTrip(
-			ctx,
-			payload.TripID,
-			"payed",
-			nil,
-		)
+	subscriptions := messaging.NewSubscriptions()
+	for _, event := range []messaging.Event[messaging.PaymentStatusUpdateData]{
+		messaging.PaymentEventSuccess,
+		messaging.PaymentEventFailed,
+		messaging.PaymentEventCancelled,
+	} {
+		messaging.Subscribe(subscriptions, event, c.handlePaymentStatus)
+	}
+	messaging.Subscribe(subscriptions, messaging.PaymentEventChargeScheduled, c.handleChargeScheduled)
+	messaging.Subscribe(subscriptions, messaging.PaymentEventRefunded, c.handleRefund)
+	messaging.Subscribe(subscriptions, messaging.PaymentEventTipReceived, c.handleTipOutcome)
+	messaging.Subscribe(subscriptions, messaging.PaymentEventTipFailed, c.handleTipOutcome)
+
+	return c.rabbitmq.ConsumeMessages(
+		messaging.PaymentStatusQueue,
+		messaging.DefaultConsumerConfig(),
+		messaging.Deduplicate(c.dedup, messaging.PaymentStatusQueue, subscriptions.Handle),
+	)
+}
+
+func (c *paymentConsumer) handlePaymentStatus(ctx context.Context, msg messaging.Message[messaging.PaymentStatusUpdateData]) error {
+	var status string
+	switch msg.RoutingKey {
+	case contracts.PaymentEventSuccess:
+		status = "payed"
+	case contracts.PaymentEventFailed:
+		status = "payment_failed"
+	case contracts.PaymentEventCancelled:
+		status = "payment_cancelled"
+	}
+
+	log.Printf("Payment for trip %s: %s", msg.Data.TripID, status)
+
+	return c.service.RecordPaymentOutcome(ctx, msg.Data.TripID, status)
+}
+
+// handleChargeScheduled records that the fare is charged to the rider's saved payment method once the trip completes
+func (c *paymentConsumer) handleChargeScheduled(ctx context.Context, msg messaging.Message[messaging.PaymentChargeScheduledData]) error {
+	log.Printf("Payment for trip %s: payment_scheduled", msg.Data.TripID)
+
+	return c.service.RecordPaymentOutcome(ctx, msg.Data.TripID, "payment_scheduled")
+}
+
+func (c *paymentConsumer) handleRefund(ctx context.Context, msg messaging.Message[messaging.PaymentRefundedData]) error {
+	payload := msg.Data
+
+	log.Printf("Refunded %s on trip %s: %s", payload.Amount, payload.TripID, payload.Reason)
+
+	return c.service.RecordRefund(ctx, payload.TripID, &domain.TripRefund{
+		RefundID:  payload.RefundID,
+		PaymentID: payload.PaymentID,
+		Amount:    payload.Amount,
+		Reason:    payload.Reason,
+		CreatedAt: time.Now(),
 	})
 }
+
+func (c *paymentConsumer) handleTipOutcome(ctx context.Context, msg messaging.Message[messaging.PaymentTipData]) error {
+	payload := msg.Data
+
+	status := domain.TipStatusReceived
+	if msg.RoutingKey == contracts.PaymentEventTipFailed {
+		status = domain.TipStatusFailed
+	}
+
+	log.Printf("Tip %s of %s on trip %s: %s", payload.TipID, payload.Amount, payload.TripID, status)
+
+	return c.service.RecordTipOutcome(ctx, payload.TripID, payload.TipID, status, payload.Reason)
+}
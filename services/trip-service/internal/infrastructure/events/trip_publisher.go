@@ -2,10 +2,8 @@ package events
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/tenteedee/mini-uber/services/trip-service/internal/domain"
-	"github.com/tenteedee/mini-uber/shared/contracts"
 	"github.com/tenteedee/mini-uber/shared/messaging"
 )
 
@@ -20,22 +18,18 @@ func NewTripEventPublisher(rabbitmq *messaging.RabbitMQ) *TripEventPublisher {
 }
 
 func (p *TripEventPublisher) PublishTripCreatedEvent(ctx context.Context, trip *domain.TripModel) error {
-	payload := messaging.TripEventData{
+	return messaging.Publish(ctx, p.rabbitmq, messaging.TripEventCreated, trip.UserID, messaging.TripEventData{
 		Trip: trip.ToProto(),
-	}
-
-	tripEventJSON, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	return p.rabbitmq.PublishMessage(
-		ctx,
-		contracts.TripEventCreated,
-		contracts.AmqpMessage{
-			OwnerID: trip.UserID,
-			Data:    tripEventJSON,
-		},
-	)
+	})
+}
 
+// PublishTipRequested asks the payment service to charge the tip
+func (p *TripEventPublisher) PublishTipRequested(ctx context.Context, trip *domain.TripModel, tip *domain.TripTip) error {
+	return messaging.Publish(ctx, p.rabbitmq, messaging.PaymentCmdCreateTip, trip.UserID, messaging.PaymentTipData{
+		TripID:   trip.ID.Hex(),
+		TipID:    tip.TipID,
+		UserID:   trip.UserID,
+		DriverID: trip.Driver.GetId(),
+		Amount:   tip.Amount,
+	})
 }
@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"errors"
+
+	"github.com/tenteedee/mini-uber/services/trip-service/internal/domain"
+	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/grpcerr"
+	"google.golang.org/grpc/codes"
+)
+
+// toStatusError translates a domain error into a gRPC status error with a stable reason.
+// Unknown errors become Internal without leaking their message to the caller.
+func toStatusError(err error) error {
+	switch {
+	case grpcerr.IsStatus(err):
+		return err
+	case errors.Is(err, domain.ErrFareNotOwned):
+		return grpcerr.New(codes.PermissionDenied, contracts.ErrCodeFareNotOwned, "ride fare does not belong to the user")
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return grpcerr.New(codes.PermissionDenied, contracts.ErrCodePermissionDenied, "permission denied")
+	case errors.Is(err, domain.ErrFareNotFound):
+		return grpcerr.New(codes.NotFound, contracts.ErrCodeFareNotFound, "ride fare not found")
+	case errors.Is(err, domain.ErrTripNotFound):
+		return grpcerr.New(codes.NotFound, contracts.ErrCodeTripNotFound, "trip not found")
+	case errors.Is(err, domain.ErrTripNotCompleted):
+		return grpcerr.New(codes.FailedPrecondition, contracts.ErrCodeTripNotCompleted, "the trip is not completed yet")
+	case errors.Is(err, domain.ErrInvalidTipAmount):
+		return grpcerr.New(codes.InvalidArgument, contracts.ErrCodeInvalidTipAmount, "the tip must be in the currency of the fare and at most the fare")
+	case errors.Is(err, domain.ErrTipAlreadyGiven):
+		return grpcerr.New(codes.AlreadyExists, contracts.ErrCodeTipAlreadyGiven, "the trip already has a tip")
+	case errors.Is(err, domain.ErrRouteNotFound):
+		return grpcerr.New(codes.FailedPrecondition, contracts.ErrCodeRouteNotFound, "no route found between pickup and destination")
+	case errors.Is(err, domain.ErrRoutingUnavailable):
+		return grpcerr.New(codes.Unavailable, contracts.ErrCodeRouteUnavailable, "routing service is unavailable, try again later")
+	default:
+		return grpcerr.New(codes.Internal, contracts.ErrCodeInternal, "internal error")
+	}
+}
@@ -9,8 +9,6 @@ import (
 	pb "github.com/tenteedee/mini-uber/shared/proto/trip"
 	"github.com/tenteedee/mini-uber/shared/types"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type gRPCHandler struct {
@@ -32,38 +30,30 @@ func NewgRPCHandler(server *grpc.Server, service domain.TripService, publisher *
 func (h *gRPCHandler) PreviewTrip(ctx context.Context, req *pb.PreviewTripRequest) (*pb.PreviewTripResponse, error) {
 	log.Printf("PreviewTrip called: pickup=%v dest=%v", req.GetPickup(), req.GetDestination())
 
-	pickup := req.GetPickup()
-	destination := req.GetDestination()
-
-	pickupCoordinates := &types.Coordinate{
-		Latitude:  pickup.GetLatitude(),
-		Longitude: pickup.GetLongitude(),
-	}
-	destinationCoordinates := &types.Coordinate{
-		Latitude:  destination.GetLatitude(),
-		Longitude: destination.GetLongitude(),
+	if err := validatePreviewTripRequest(req); err != nil {
+		return nil, err
 	}
 
 	route, err := h.service.GetTripRoute(ctx,
-		pickupCoordinates,
-		destinationCoordinates,
+		toCoordinate(req.GetPickup()),
+		toCoordinate(req.GetDestination()),
 		true,
 	)
 	if err != nil {
-		log.Println(err)
-		return nil, status.Errorf(codes.Internal, "failed to get route %v: ", err)
+		log.Printf("failed to get route: %v", err)
+		return nil, toStatusError(err)
 	}
 
 	userID := req.GetUserID()
 
-	// estimate the ride fares price based on the route
-	estimatedFares := h.service.EstimatePackagesPriceWithRoutes(route)
+	// estimate the ride fares price based on the route, in the currency of the pickup's region
+	estimatedFares := h.service.EstimatePackagesPriceWithRoutes(toCoordinate(req.GetPickup()), route)
 
 	// store the ride fares for creating trip later
 	fares, err := h.service.GenerateTripFares(ctx, estimatedFares, userID, route)
 	if err != nil {
-		log.Println(err)
-		return nil, status.Errorf(codes.Internal, "failed to generate trip fares: %v", err)
+		log.Printf("failed to generate trip fares: %v", err)
+		return nil, toStatusError(err)
 	}
 
 	return &pb.PreviewTripResponse{
@@ -73,27 +63,62 @@ func (h *gRPCHandler) PreviewTrip(ctx context.Context, req *pb.PreviewTripReques
 }
 
 func (h *gRPCHandler) CreateTrip(ctx context.Context, req *pb.CreateTripRequest) (*pb.CreateTripResponse, error) {
+	if err := validateCreateTripRequest(req); err != nil {
+		return nil, err
+	}
+
 	fareID := req.GetRideFareID()
 	userID := req.GetUserID()
 
 	rideFare, err := h.service.GetAndValidateFare(ctx, fareID, userID)
 	if err != nil {
-		log.Println(err)
-		return nil, status.Errorf(codes.Internal, "failed to get and validate fare: %v", err)
+		log.Printf("failed to get and validate fare %s for user %s: %v", fareID, userID, err)
+		return nil, toStatusError(err)
 	}
 
 	trip, err := h.service.CreateTrip(ctx, rideFare)
 	if err != nil {
-		log.Println(err)
-		return nil, status.Errorf(codes.Internal, "failed to create trip: %v", err)
+		log.Printf("failed to create trip: %v", err)
+		return nil, toStatusError(err)
 	}
 
 	if err := h.publisher.PublishTripCreatedEvent(ctx, trip); err != nil {
-		log.Println(err)
-		return nil, status.Errorf(codes.Internal, "failed to publish trip created event: %v", err)
+		log.Printf("failed to publish trip created event: %v", err)
+		return nil, toStatusError(err)
 	}
 
 	return &pb.CreateTripResponse{
 		TripID: trip.ID.Hex(),
 	}, nil
 }
+
+func (h *gRPCHandler) TipTrip(ctx context.Context, req *pb.TipTripRequest) (*pb.TipTripResponse, error) {
+	if err := validateTipTripRequest(req); err != nil {
+		return nil, err
+	}
+
+	actor := domain.Actor{ID: req.GetUserID(), Role: domain.RoleRider}
+	amount := types.MoneyFromProto(req.GetAmount())
+
+	trip, tip, err := h.service.TipTrip(ctx, req.GetTripID(), actor, amount)
+	if err != nil {
+		log.Printf("failed to tip trip %s: %v", req.GetTripID(), err)
+		return nil, toStatusError(err)
+	}
+
+	if err := h.publisher.PublishTipRequested(ctx, trip, tip); err != nil {
+		log.Printf("failed to publish tip %s of trip %s: %v", tip.TipID, req.GetTripID(), err)
+
+		// let the rider try again instead of leaving a tip that is never charged
+		if err := h.service.RecordTipOutcome(ctx, req.GetTripID(), tip.TipID, domain.TipStatusFailed, "could not be sent for payment"); err != nil {
+			log.Printf("failed to mark tip %s as failed: %v", tip.TipID, err)
+		}
+		return nil, toStatusError(err)
+	}
+
+	return &pb.TipTripResponse{
+		TipID:  tip.TipID,
+		Amount: tip.Amount.ToProto(),
+		Status: tip.Status,
+	}, nil
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/env"
+	"github.com/tenteedee/mini-uber/shared/grpcerr"
+	pb "github.com/tenteedee/mini-uber/shared/proto/trip"
+	"github.com/tenteedee/mini-uber/shared/types"
+	"github.com/tenteedee/mini-uber/shared/validation"
+)
+
+// straight line limit between pickup and destination, the api-gateway enforces the same limit
+var maxTripDistanceKm = env.GetInt("MAX_TRIP_DISTANCE_KM", 100)
+
+// the field errors name the fields of the api-gateway JSON, the same as when the api-gateway rejects the request
+
+func validatePreviewTripRequest(req *pb.PreviewTripRequest) error {
+	v := validation.New()
+	v.Required("userId", req.GetUserID())
+	v.Trip(
+		"pickup", toCoordinate(req.GetPickup()),
+		"destination", toCoordinate(req.GetDestination()),
+		float64(maxTripDistanceKm*1000),
+	)
+
+	if !v.Valid() {
+		return grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid preview trip request", v.Errors())
+	}
+	return nil
+}
+
+func validateCreateTripRequest(req *pb.CreateTripRequest) error {
+	v := validation.New()
+	v.Required("rideFareId", req.GetRideFareID())
+	v.Required("userId", req.GetUserID())
+
+	if !v.Valid() {
+		return grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid create trip request", v.Errors())
+	}
+	return nil
+}
+
+func validateTipTripRequest(req *pb.TipTripRequest) error {
+	v := validation.New()
+	v.Required("tripId", req.GetTripID())
+	v.Required("userId", req.GetUserID())
+	v.Required("amount.currency", req.GetAmount().GetCurrency())
+	if req.GetAmount().GetAmount() <= 0 {
+		v.AddError("amount.amount", "must be positive")
+	}
+
+	if !v.Valid() {
+		return grpcerr.Invalid(contracts.ErrCodeValidationFailed, "invalid tip trip request", v.Errors())
+	}
+	return nil
+}
+
+func toCoordinate(c *pb.Coordinate) *types.Coordinate {
+	if c == nil {
+		return nil
+	}
+
+	return &types.Coordinate{
+		Latitude:  c.GetLatitude(),
+		Longitude: c.GetLongitude(),
+	}
+}
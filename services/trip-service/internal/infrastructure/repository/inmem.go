@@ -30,7 +30,7 @@ func (r *inmemRepository) CreateTrip(ctx context.Context, trip *domain.TripModel
 func (r *inmemRepository) GetTripByID(ctx context.Context, id string) (*domain.TripModel, error) {
 	trip, ok := r.trips[id]
 	if !ok {
-		return nil, nil
+		return nil, domain.ErrTripNotFound
 	}
 	return trip, nil
 }
@@ -38,7 +38,7 @@ func (r *inmemRepository) GetTripByID(ctx context.Context, id string) (*domain.T
 func (r *inmemRepository) UpdateTrip(ctx context.Context, tripID string, status string, driver *pbd.Driver) error {
 	trip, ok := r.trips[tripID]
 	if !ok {
-		return fmt.Errorf("trip not found with ID: %s", tripID)
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
 	}
 
 	trip.Status = status
@@ -54,6 +54,75 @@ func (r *inmemRepository) UpdateTrip(ctx context.Context, tripID string, status
 	return nil
 }
 
+func (r *inmemRepository) SetOfferedDriver(ctx context.Context, tripID string, driverID string) error {
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	trip.OfferedDriverID = driverID
+	return nil
+}
+
+func (r *inmemRepository) ClearOfferedDriver(ctx context.Context, tripID string, driverID string) error {
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	if trip.OfferedDriverID == driverID {
+		trip.OfferedDriverID = ""
+	}
+	return nil
+}
+
+func (r *inmemRepository) AddRefund(ctx context.Context, tripID string, refund *domain.TripRefund) error {
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	for _, existing := range trip.Refunds {
+		if existing.RefundID == refund.RefundID {
+			return nil
+		}
+	}
+
+	trip.Refunds = append(trip.Refunds, refund)
+	return nil
+}
+
+func (r *inmemRepository) SetTip(ctx context.Context, tripID string, tip *domain.TripTip) error {
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	if trip.Status != "completed" {
+		return fmt.Errorf("%w: trip %s is %s", domain.ErrTripNotCompleted, tripID, trip.Status)
+	}
+
+	if trip.Tip != nil && trip.Tip.Status != domain.TipStatusFailed {
+		return fmt.Errorf("%w: %s", domain.ErrTipAlreadyGiven, tripID)
+	}
+
+	trip.Tip = tip
+	return nil
+}
+
+func (r *inmemRepository) UpdateTipStatus(ctx context.Context, tripID string, tipID string, status string, reason string) error {
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	if trip.Tip != nil && trip.Tip.TipID == tipID {
+		trip.Tip.Status = status
+		trip.Tip.FailureReason = reason
+	}
+	return nil
+}
+
 func (r *inmemRepository) SaveRideFare(ctx context.Context, fare *domain.RideFareModel) error {
 	r.rideFares[fare.ID.Hex()] = fare
 	return nil
@@ -63,7 +132,7 @@ func (r *inmemRepository) GetRideFareByID(ctx context.Context, fareID string) (*
 	log.Println(r.rideFares)
 	fare, exists := r.rideFares[fareID]
 	if !exists {
-		return nil, fmt.Errorf("fare does not exists with ID: %s", fareID)
+		return nil, fmt.Errorf("%w: %s", domain.ErrFareNotFound, fareID)
 	}
 	return fare, nil
 }
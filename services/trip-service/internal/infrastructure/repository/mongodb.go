@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/tenteedee/mini-uber/services/trip-service/internal/domain"
@@ -35,11 +36,14 @@ func (r *mongoRepository) CreateTrip(ctx context.Context, trip *domain.TripModel
 func (r *mongoRepository) GetTripByID(ctx context.Context, id string) (*domain.TripModel, error) {
 	_id, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s", domain.ErrTripNotFound, id)
 	}
 
 	result := r.db.Collection(db.TripsCollection).FindOne(ctx, bson.M{"_id": _id})
 	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrTripNotFound, id)
+		}
 		return nil, result.Err()
 	}
 
@@ -69,8 +73,127 @@ func (r *mongoRepository) UpdateTrip(ctx context.Context, tripID string, status
 		return err
 	}
 
-	if result.ModifiedCount == 0 {
-		return fmt.Errorf("trip not found: %s", tripID)
+	// an update to the status the trip already has, e.g. a payment outcome delivered twice, is no error
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) SetOfferedDriver(ctx context.Context, tripID string, driverID string) error {
+	_id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	result, err := r.db.Collection(db.TripsCollection).UpdateOne(ctx,
+		bson.M{"_id": _id},
+		bson.M{"$set": bson.M{"offeredDriverId": driverID}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) ClearOfferedDriver(ctx context.Context, tripID string, driverID string) error {
+	_id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	// only the offer of the driver, the next one may already be recorded
+	_, err = r.db.Collection(db.TripsCollection).UpdateOne(ctx,
+		bson.M{"_id": _id, "offeredDriverId": driverID},
+		bson.M{"$unset": bson.M{"offeredDriverId": ""}},
+	)
+	return err
+}
+
+func (r *mongoRepository) AddRefund(ctx context.Context, tripID string, refund *domain.TripRefund) error {
+	_id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	// refund events can be delivered more than once, only push refunds not recorded yet
+	result, err := r.db.Collection(db.TripsCollection).UpdateOne(ctx,
+		bson.M{"_id": _id, "refunds.refundId": bson.M{"$ne": refund.RefundID}},
+		bson.M{"$push": bson.M{"refunds": refund}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := r.GetTripByID(ctx, tripID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) SetTip(ctx context.Context, tripID string, tip *domain.TripTip) error {
+	_id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	// only one tip per trip, unless the previous one could not be charged
+	result, err := r.db.Collection(db.TripsCollection).UpdateOne(ctx,
+		bson.M{
+			"_id":    _id,
+			"status": "completed",
+			"$or": bson.A{
+				bson.M{"tip": bson.M{"$exists": false}},
+				bson.M{"tip.status": domain.TipStatusFailed},
+			},
+		},
+		bson.M{"$set": bson.M{"tip": tip}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		trip, err := r.GetTripByID(ctx, tripID)
+		if err != nil {
+			return err
+		}
+		if trip.Status != "completed" {
+			return fmt.Errorf("%w: trip %s is %s", domain.ErrTripNotCompleted, tripID, trip.Status)
+		}
+		return fmt.Errorf("%w: %s", domain.ErrTipAlreadyGiven, tripID)
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) UpdateTipStatus(ctx context.Context, tripID string, tipID string, status string, reason string) error {
+	_id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	result, err := r.db.Collection(db.TripsCollection).UpdateOne(ctx,
+		bson.M{"_id": _id, "tip.tipId": tipID},
+		bson.M{"$set": bson.M{"tip.status": status, "tip.failureReason": reason}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := r.GetTripByID(ctx, tripID); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -90,11 +213,14 @@ func (r *mongoRepository) SaveRideFare(ctx context.Context, fare *domain.RideFar
 func (r *mongoRepository) GetRideFareByID(ctx context.Context, id string) (*domain.RideFareModel, error) {
 	_id, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
 	}
 
 	result := r.db.Collection(db.RideFaresCollection).FindOne(ctx, bson.M{"_id": _id})
 	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
+		}
 		return nil, result.Err()
 	}
 
@@ -7,7 +7,9 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/tenteedee/mini-uber/services/trip-service/internal/domain"
 	tripTypes "github.com/tenteedee/mini-uber/services/trip-service/pkg/types"
 	pbd "github.com/tenteedee/mini-uber/shared/proto/driver"
@@ -50,43 +52,58 @@ func (s *service) GetTripRoute(ctx context.Context, pickup *types.Coordinate, de
 		destination.Longitude, destination.Latitude,
 	)
 
-	response, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch route from OSRM API: %v", err)
+		return nil, fmt.Errorf("failed to build OSRM API request: %v", err)
+	}
+
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch route from OSRM API: %v: %w", err, domain.ErrRoutingUnavailable)
 	}
 	defer response.Body.Close()
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read OSRM API response body: %v", err)
+		return nil, fmt.Errorf("failed to read OSRM API response body: %v: %w", err, domain.ErrRoutingUnavailable)
 	}
 
 	log.Println("OSRM Status:", response.StatusCode)
 	log.Println("OSRM Body:", string(body))
 
+	if response.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("OSRM API returned status %d: %w", response.StatusCode, domain.ErrRoutingUnavailable)
+	}
+
 	var routeResponse tripTypes.OsrmApiResponse
 
 	if err := json.Unmarshal(body, &routeResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal OSRM API response: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal OSRM API response: %v: %w", err, domain.ErrRoutingUnavailable)
+	}
+
+	// OSRM answers "Ok" when a route was found, anything else (NoRoute, InvalidQuery...) means there is nothing to price
+	if routeResponse.Code != "Ok" || len(routeResponse.Routes) == 0 {
+		return nil, fmt.Errorf("OSRM API returned code %q: %w", routeResponse.Code, domain.ErrRouteNotFound)
 	}
 
 	return &routeResponse, nil
 }
 
-func (s *service) EstimatePackagesPriceWithRoutes(route *tripTypes.OsrmApiResponse) []*domain.RideFareModel {
-	baseFares := getBaseFares()
+// EstimatePackagesPriceWithRoutes prices every car package in the currency of the region the pickup is in.
+func (s *service) EstimatePackagesPriceWithRoutes(pickup *types.Coordinate, route *tripTypes.OsrmApiResponse) []*domain.RideFareModel {
+	pricingCfg := tripTypes.PricingFor(pickup)
+	baseFares := getBaseFares(pricingCfg)
 	estimatedFares := make([]*domain.RideFareModel, len(baseFares))
 
 	for i, fare := range baseFares {
-		estimatedFares[i] = estimateFareRoute(fare, route)
+		estimatedFares[i] = estimateFareRoute(fare, route, pricingCfg)
 	}
 
 	return estimatedFares
 }
 
-func estimateFareRoute(fare *domain.RideFareModel, route *tripTypes.OsrmApiResponse) *domain.RideFareModel {
-	pricingCfg := tripTypes.DefaultPricingConfig()
-	carPackagePrice := fare.TotalPriceInCents
+func estimateFareRoute(fare *domain.RideFareModel, route *tripTypes.OsrmApiResponse, pricingCfg *tripTypes.PricingConfig) *domain.RideFareModel {
+	carPackagePrice := float64(fare.Price.Amount)
 
 	distance := route.Routes[0].Distance
 	duration := route.Routes[0].Duration
@@ -98,9 +115,10 @@ func estimateFareRoute(fare *domain.RideFareModel, route *tripTypes.OsrmApiRespo
 	// car price
 	totalPrice := carPackagePrice + distanceFare + timeFare
 
+	// the parts are summed in fractional minor units, only the total is rounded
 	return &domain.RideFareModel{
-		PackageSlug:       fare.PackageSlug,
-		TotalPriceInCents: totalPrice,
+		PackageSlug: fare.PackageSlug,
+		Price:       types.RoundMoney(totalPrice, fare.Price.Currency),
 	}
 }
 
@@ -109,11 +127,11 @@ func (s *service) GenerateTripFares(ctx context.Context, rideFares []*domain.Rid
 
 	for i, fare := range rideFares {
 		fare := &domain.RideFareModel{
-			ID:                primitive.NewObjectID(),
-			UserID:            userId,
-			PackageSlug:       fare.PackageSlug,
-			TotalPriceInCents: fare.TotalPriceInCents,
-			Route:             route,
+			ID:          primitive.NewObjectID(),
+			UserID:      userId,
+			PackageSlug: fare.PackageSlug,
+			Price:       fare.Price,
+			Route:       route,
 		}
 		if err := s.repo.SaveRideFare(ctx, fare); err != nil {
 			return nil, fmt.Errorf("failed to save ride fare: %v", err)
@@ -128,34 +146,27 @@ func (s *service) GenerateTripFares(ctx context.Context, rideFares []*domain.Rid
 func (s *service) GetAndValidateFare(ctx context.Context, fareID string, userID string) (*domain.RideFareModel, error) {
 	fare, err := s.repo.GetRideFareByID(ctx, fareID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ride fare by ID: %v", err)
+		return nil, fmt.Errorf("failed to get ride fare by ID: %w", err)
 	}
 
 	if fare.UserID != userID {
-		return nil, fmt.Errorf("fare does not belong to user")
+		return nil, domain.ErrFareNotOwned
 	}
 	return fare, nil
 }
 
-func getBaseFares() []*domain.RideFareModel {
-	return []*domain.RideFareModel{
-		{
-			PackageSlug:       "sedan",
-			TotalPriceInCents: 200,
-		},
-		{
-			PackageSlug:       "suv",
-			TotalPriceInCents: 300,
-		},
-		{
-			PackageSlug:       "luxury",
-			TotalPriceInCents: 1000,
-		},
-		{
-			PackageSlug:       "van",
-			TotalPriceInCents: 400,
-		},
+func getBaseFares(pricingCfg *tripTypes.PricingConfig) []*domain.RideFareModel {
+	packages := []string{types.PackageSedan, types.PackageSUV, types.PackageLuxury, types.PackageVan}
+	fares := make([]*domain.RideFareModel, len(packages))
+
+	for i, packageSlug := range packages {
+		fares[i] = &domain.RideFareModel{
+			PackageSlug: packageSlug,
+			Price:       types.NewMoney(pricingCfg.BaseFares[packageSlug], pricingCfg.Currency),
+		}
 	}
+
+	return fares
 }
 
 func (s *service) GetTripById(ctx context.Context, tripId string) (*domain.TripModel, error) {
@@ -165,3 +176,96 @@ func (s *service) GetTripById(ctx context.Context, tripId string) (*domain.TripM
 func (s *service) UpdateTrip(ctx context.Context, tripId string, status string, driver *pbd.Driver) error {
 	return s.repo.UpdateTrip(ctx, tripId, status, driver)
 }
+
+func (s *service) RecordDriverOffer(ctx context.Context, tripId string, driverId string) error {
+	return s.repo.SetOfferedDriver(ctx, tripId, driverId)
+}
+
+func (s *service) WithdrawDriverOffer(ctx context.Context, tripId string, driverId string) error {
+	return s.repo.ClearOfferedDriver(ctx, tripId, driverId)
+}
+
+func (s *service) RecordRefund(ctx context.Context, tripId string, refund *domain.TripRefund) error {
+	return s.repo.AddRefund(ctx, tripId, refund)
+}
+
+func (s *service) RecordPaymentOutcome(ctx context.Context, tripId string, status string) error {
+	trip, err := s.repo.GetTripByID(ctx, tripId)
+	if err != nil {
+		return err
+	}
+
+	if trip.Status == "completed" {
+		log.Printf("Trip %s is completed, keeping it completed on payment %s", tripId, status)
+		return nil
+	}
+
+	return s.repo.UpdateTrip(ctx, tripId, status, nil)
+}
+
+// CompleteTrip marks the trip as completed, from then on the rider can tip the driver
+func (s *service) CompleteTrip(ctx context.Context, tripId string, actor domain.Actor) (*domain.TripModel, error) {
+	trip, err := s.repo.GetTripByID(ctx, tripId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := trip.CanComplete(actor); err != nil {
+		return nil, fmt.Errorf("%s %s cannot complete trip %s: %w", actor.Role, actor.ID, tripId, err)
+	}
+
+	if err := s.repo.UpdateTrip(ctx, tripId, "completed", nil); err != nil {
+		return nil, err
+	}
+
+	trip.Status = "completed"
+	return trip, nil
+}
+
+// TipTrip records a tip from the rider of a completed trip, it is charged by the payment service
+func (s *service) TipTrip(ctx context.Context, tripId string, actor domain.Actor, amount types.Money) (*domain.TripModel, *domain.TripTip, error) {
+	trip, err := s.AuthorizeTripAction(ctx, tripId, actor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if trip.Status != "completed" {
+		return nil, nil, fmt.Errorf("%w: trip %s is %s", domain.ErrTripNotCompleted, tripId, trip.Status)
+	}
+
+	if err := trip.ValidateTip(amount); err != nil {
+		return nil, nil, err
+	}
+
+	tip := &domain.TripTip{
+		TipID:     uuid.New().String(),
+		Amount:    amount,
+		Status:    domain.TipStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.SetTip(ctx, tripId, tip); err != nil {
+		return nil, nil, err
+	}
+
+	trip.Tip = tip
+	return trip, tip, nil
+}
+
+func (s *service) RecordTipOutcome(ctx context.Context, tripId string, tipId string, status string, reason string) error {
+	return s.repo.UpdateTipStatus(ctx, tripId, tipId, status, reason)
+}
+
+// AuthorizeTripAction loads the trip and checks that the actor is allowed to act on it.
+func (s *service) AuthorizeTripAction(ctx context.Context, tripId string, actor domain.Actor) (*domain.TripModel, error) {
+	trip, err := s.repo.GetTripByID(ctx, tripId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := trip.CanAct(actor); err != nil {
+		return nil, fmt.Errorf("%s %s cannot act on trip %s: %w", actor.Role, actor.ID, tripId, err)
+	}
+
+	return trip, nil
+}
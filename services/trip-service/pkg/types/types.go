@@ -1,6 +1,9 @@
 package types
 
-import pb "github.com/tenteedee/mini-uber/shared/proto/trip"
+import (
+	pb "github.com/tenteedee/mini-uber/shared/proto/trip"
+	"github.com/tenteedee/mini-uber/shared/types"
+)
 
 // type OsrmApiResponse struct {
 // 	Routes []struct {
@@ -46,14 +49,75 @@ func (o *OsrmApiResponse) ToProto() *pb.Route {
 	}
 }
 
+// PricingConfig holds the rates of a pricing region, in minor units of its currency.
 type PricingConfig struct {
+	Currency               string
 	PricePerUnitOfDistance float64
 	PricePerMinute         float64
+	// BaseFares is the flat price of each car package
+	BaseFares map[string]int64
+}
+
+// PricingRegion is the service area a pricing config applies to, a box of coordinates.
+type PricingRegion struct {
+	Name         string
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+	Pricing      *PricingConfig
+}
+
+func (r *PricingRegion) Contains(coord *types.Coordinate) bool {
+	return coord.Latitude >= r.MinLatitude && coord.Latitude <= r.MaxLatitude &&
+		coord.Longitude >= r.MinLongitude && coord.Longitude <= r.MaxLongitude
 }
 
 func DefaultPricingConfig() *PricingConfig {
 	return &PricingConfig{
+		Currency:               types.DefaultCurrency,
 		PricePerUnitOfDistance: 1.0,
 		PricePerMinute:         0.25,
+		BaseFares: map[string]int64{
+			types.PackageSedan:  200,
+			types.PackageSUV:    300,
+			types.PackageLuxury: 1000,
+			types.PackageVan:    400,
+		},
+	}
+}
+
+// PricingRegions are the service areas with their own currency and rates,
+// a pickup outside all of them is priced with DefaultPricingConfig.
+func PricingRegions() []*PricingRegion {
+	return []*PricingRegion{
+		{
+			Name:         "hanoi",
+			MinLatitude:  20.85,
+			MaxLatitude:  21.25,
+			MinLongitude: 105.55,
+			MaxLongitude: 106.05,
+			Pricing: &PricingConfig{
+				Currency:               "VND",
+				PricePerUnitOfDistance: 10,
+				PricePerMinute:         2.5,
+				BaseFares: map[string]int64{
+					types.PackageSedan:  10000,
+					types.PackageSUV:    15000,
+					types.PackageLuxury: 50000,
+					types.PackageVan:    20000,
+				},
+			},
+		},
+	}
+}
+
+// PricingFor returns the pricing config of the region the pickup is in.
+func PricingFor(pickup *types.Coordinate) *PricingConfig {
+	for _, region := range PricingRegions() {
+		if region.Contains(pickup) {
+			return region.Pricing
+		}
 	}
+	return DefaultPricingConfig()
 }
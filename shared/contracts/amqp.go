@@ -1,6 +1,6 @@
 package contracts
 
-// AmqpMessage is the message structure for AMQP.
+// AmqpMessage is the envelope of the messages published before Envelope, it is still read while services migrate
 type AmqpMessage struct {
 	OwnerID string `json:"ownerId"`
 	Data    []byte `json:"data"`
@@ -13,20 +13,28 @@ const (
 	TripEventDriverAssigned      = "trip.event.driver_assigned"
 	TripEventNoDriversFound      = "trip.event.no_drivers_found"
 	TripEventDriverNotInterested = "trip.event.driver_not_interested"
+	TripEventCompleted           = "trip.event.completed"
 
 	// Driver commands (driver.cmd.*)
-	DriverCmdTripRequest = "driver.cmd.trip_request"
-	DriverCmdTripAccept  = "driver.cmd.trip_accept"
-	DriverCmdTripDecline = "driver.cmd.trip_decline"
-	DriverCmdLocation    = "driver.cmd.location"
-	DriverCmdRegister    = "driver.cmd.register"
+	DriverCmdTripRequest  = "driver.cmd.trip_request"
+	DriverCmdTripAccept   = "driver.cmd.trip_accept"
+	DriverCmdTripDecline  = "driver.cmd.trip_decline"
+	DriverCmdTripComplete = "driver.cmd.trip_complete"
+	DriverCmdLocation     = "driver.cmd.location"
+	DriverCmdRegister     = "driver.cmd.register"
 
 	// Payment events (payment.event.*)
 	PaymentEventSessionCreated = "payment.event.session_created"
 	PaymentEventSuccess        = "payment.event.success"
 	PaymentEventFailed         = "payment.event.failed"
 	PaymentEventCancelled      = "payment.event.cancelled"
+	PaymentEventRefunded       = "payment.event.refunded"
+	PaymentEventTipReceived    = "payment.event.tip_received"
+	PaymentEventTipFailed      = "payment.event.tip_failed"
+	// PaymentEventChargeScheduled tells the fare will be charged to the rider's saved payment method once the trip completes
+	PaymentEventChargeScheduled = "payment.event.charge_scheduled"
 
 	// Payment commands (payment.cmd.*)
 	PaymentCmdCreateSession = "payment.cmd.create_session"
+	PaymentCmdCreateTip     = "payment.cmd.create_tip"
 )
@@ -0,0 +1,166 @@
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType is the content type of the messages in the Envelope, the legacy AmqpMessage is application/json
+	CloudEventsContentType = "application/cloudevents+json"
+	// CloudEventsHeaderPrefix prefixes the attributes of the messages in the binary content mode, they are
+	// in the headers and the body is the data
+	CloudEventsHeaderPrefix = "cloudEvents:"
+)
+
+var ErrInvalidEnvelope = errors.New("invalid message envelope")
+
+// Envelope wraps the data of the messages in the CloudEvents 1.0 JSON format, or carries their attributes
+// in the binary content mode. Messages published before it are an AmqpMessage, DecodeMessage reads all of them.
+type Envelope struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"` // the service that published the message
+	Type            string    `json:"type"`   // the routing key
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype,omitempty"`
+	DataSchema      string    `json:"dataschema,omitempty"` // names the payload of the type and its version
+	// extension attributes: the message that started the flow, the one whose handler published this message,
+	// and the user the message is for
+	CorrelationID string          `json:"correlationid,omitempty"`
+	CausationID   string          `json:"causationid,omitempty"`
+	OwnerID       string          `json:"ownerid,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+	// DataBase64 holds the data when it isn't JSON, e.g. a protobuf message
+	DataBase64 []byte `json:"data_base64,omitempty"`
+}
+
+// IsLegacy tells the envelope was read from an AmqpMessage, it has no metadata but the owner
+func (e *Envelope) IsLegacy() bool {
+	return e.SpecVersion == ""
+}
+
+// Payload is the data of the envelope, whatever its content type
+func (e *Envelope) Payload() []byte {
+	if e.DataBase64 != nil {
+		return e.DataBase64
+	}
+	return e.Data
+}
+
+// Headers are the attributes of the envelope in the binary content mode, the data is the body of the message
+func (e *Envelope) Headers() map[string]any {
+	headers := map[string]any{
+		CloudEventsHeaderPrefix + "specversion": e.SpecVersion,
+		CloudEventsHeaderPrefix + "id":          e.ID,
+		CloudEventsHeaderPrefix + "source":      e.Source,
+		CloudEventsHeaderPrefix + "type":        e.Type,
+		CloudEventsHeaderPrefix + "time":        e.Time.Format(time.RFC3339Nano),
+	}
+
+	optional := map[string]string{
+		"dataschema":    e.DataSchema,
+		"correlationid": e.CorrelationID,
+		"causationid":   e.CausationID,
+		"ownerid":       e.OwnerID,
+	}
+	for name, value := range optional {
+		if value != "" {
+			headers[CloudEventsHeaderPrefix+name] = value
+		}
+	}
+
+	return headers
+}
+
+// DecodeMessage reads a message in either content mode: with the attributes in its headers the body is
+// the data in the content type, otherwise the body is an envelope
+func DecodeMessage(contentType string, headers map[string]any, body []byte) (*Envelope, error) {
+	if _, ok := headers[CloudEventsHeaderPrefix+"specversion"]; !ok {
+		return DecodeEnvelope(body)
+	}
+
+	attribute := func(name string) string {
+		value, _ := headers[CloudEventsHeaderPrefix+name].(string)
+		return value
+	}
+
+	envelope := Envelope{
+		SpecVersion:     attribute("specversion"),
+		ID:              attribute("id"),
+		Source:          attribute("source"),
+		Type:            attribute("type"),
+		DataContentType: contentType,
+		DataSchema:      attribute("dataschema"),
+		CorrelationID:   attribute("correlationid"),
+		CausationID:     attribute("causationid"),
+		OwnerID:         attribute("ownerid"),
+	}
+
+	if value := attribute("time"); value != "" {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: time: %v", ErrInvalidEnvelope, err)
+		}
+		envelope.Time = t
+	}
+
+	if strings.HasSuffix(mediaType(contentType), "json") {
+		envelope.Data = body
+	} else {
+		envelope.DataBase64 = body
+	}
+
+	if err := envelope.validate(); err != nil {
+		return nil, err
+	}
+
+	return &envelope, nil
+}
+
+// mediaType is the content type without its parameters
+func mediaType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// DecodeEnvelope reads a message in either envelope format
+func DecodeEnvelope(body []byte) (*Envelope, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+
+	if _, ok := fields["specversion"]; !ok {
+		var legacy AmqpMessage
+		if err := json.Unmarshal(body, &legacy); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+		}
+		return &Envelope{OwnerID: legacy.OwnerID, Data: legacy.Data}, nil
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+
+	if err := envelope.validate(); err != nil {
+		return nil, err
+	}
+
+	return &envelope, nil
+}
+
+func (e *Envelope) validate() error {
+	if e.SpecVersion != CloudEventsSpecVersion {
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidEnvelope, e.SpecVersion)
+	}
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return fmt.Errorf("%w: id, source and type are required", ErrInvalidEnvelope)
+	}
+	return nil
+}
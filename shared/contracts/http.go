@@ -8,6 +8,51 @@ type APIResponse struct {
 
 // APIError is the error structure for the API.
 type APIError struct {
-	Code    string `json:"code"`
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Fields  []APIFieldError `json:"fields,omitempty"`
+}
+
+// APIFieldError tells the client which request field is invalid and why.
+type APIFieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
 }
+
+// Error codes returned in APIError.Code. These are part of the public API and must stay stable.
+// Backend services use the same values as the reason of their gRPC errors.
+const (
+	// Generic errors, derived from the HTTP/gRPC status when no specific reason is known
+	ErrCodeInvalidRequest   = "invalid_request"
+	ErrCodeValidationFailed = "validation_failed"
+	ErrCodeUnauthenticated  = "unauthenticated"
+	ErrCodePermissionDenied = "permission_denied"
+	ErrCodeNotFound         = "not_found"
+	ErrCodeConflict         = "conflict"
+	ErrCodeRateLimited      = "rate_limited"
+	ErrCodeUnavailable      = "service_unavailable"
+	ErrCodeTimeout          = "timeout"
+	ErrCodeInternal         = "internal_error"
+
+	// Gateway errors
+	ErrCodeTooManyConnections = "too_many_connections"
+
+	// Trip errors
+	ErrCodeTripNotFound     = "trip_not_found"
+	ErrCodeFareNotFound     = "fare_not_found"
+	ErrCodeFareNotOwned     = "fare_not_owned"
+	ErrCodeRouteNotFound    = "route_not_found"
+	ErrCodeRouteUnavailable = "route_unavailable"
+	ErrCodeTripNotCompleted = "trip_not_completed"
+	ErrCodeInvalidTipAmount = "invalid_tip_amount"
+	ErrCodeTipAlreadyGiven  = "tip_already_given"
+
+	// Driver errors
+	ErrCodeDriverRegistrationFailed = "driver_registration_failed"
+
+	// Payment errors
+	ErrCodePaymentNotFound       = "payment_not_found"
+	ErrCodePaymentNotRefundable  = "payment_not_refundable"
+	ErrCodeInvalidRefundAmount   = "invalid_refund_amount"
+	ErrCodePaymentMethodNotFound = "payment_method_not_found"
+)
@@ -13,8 +13,15 @@ import (
 )
 
 const (
-	TripsCollection     = "trips"
-	RideFaresCollection = "ride_fares"
+	TripsCollection         = "trips"
+	RideFaresCollection     = "ride_fares"
+	PaymentsCollection      = "payments"
+	RefundsCollection       = "refunds"
+	LedgerEntriesCollection = "ledger_entries"
+	PayoutsCollection       = "payouts"
+	CustomersCollection     = "customers"
+	// ProcessedMessagesCollection remembers the messages each consumer handled
+	ProcessedMessagesCollection = "processed_messages"
 )
 
 type MongoConfig struct {
@@ -0,0 +1,108 @@
+/*
+Package grpcerr builds gRPC status errors that carry a stable, machine-readable
+reason, so that callers (e.g. the api-gateway) don't have to parse error messages.
+*/
+package grpcerr
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tenteedee/mini-uber/shared/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain identifies the system that produced the error in the ErrorInfo detail.
+const Domain = "mini-uber"
+
+// New returns a gRPC status error with the given code and an ErrorInfo detail holding the reason.
+func New(code codes.Code, reason string, message string) error {
+	st := status.New(code, message)
+
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: Domain,
+	})
+	if err != nil {
+		// details can only fail to marshal for an OK status, fall back to the plain status
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// Newf is like New but formats the message.
+func Newf(code codes.Code, reason string, format string, args ...any) error {
+	return New(code, reason, fmt.Sprintf(format, args...))
+}
+
+// Invalid returns an InvalidArgument status error carrying the reason and a
+// BadRequest detail listing every invalid field.
+func Invalid(reason string, message string, fieldErrors []validation.FieldError) error {
+	st := status.New(codes.InvalidArgument, message)
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(fieldErrors))
+	for i, fieldErr := range fieldErrors {
+		violations[i] = &errdetails.BadRequest_FieldViolation{
+			Field:       fieldErr.Field,
+			Description: fieldErr.Message,
+		}
+	}
+
+	withDetails, err := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: reason, Domain: Domain},
+		&errdetails.BadRequest{FieldViolations: violations},
+	)
+	if err != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// FieldErrors returns the invalid fields attached to a gRPC status error by Invalid.
+func FieldErrors(err error) []validation.FieldError {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+
+	var fieldErrors []validation.FieldError
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fieldErrors = append(fieldErrors, validation.FieldError{
+					Field:   violation.GetField(),
+					Message: violation.GetDescription(),
+				})
+			}
+		}
+	}
+
+	return fieldErrors
+}
+
+// Reason returns the reason attached to a gRPC status error, or an empty string if there is none.
+func Reason(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return ""
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+
+	return ""
+}
+
+// IsStatus reports whether err already is a gRPC status error, so handlers
+// can pass it through instead of wrapping it again.
+func IsStatus(err error) bool {
+	var st interface{ GRPCStatus() *status.Status }
+	return errors.As(err, &st)
+}
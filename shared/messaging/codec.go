@@ -0,0 +1,170 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Content types of the message data
+const (
+	ContentTypeJSON      = "application/json"
+	ContentTypeProtoJSON = "application/protobuf+json"
+	ContentTypeProtobuf  = "application/protobuf"
+)
+
+var (
+	// ErrUnsupportedContentType is returned for a message whose data no codec reads
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+	// ErrNotProtoPayload is returned when a protobuf codec is given a payload without a protobuf message
+	ErrNotProtoPayload = errors.New("payload has no protobuf message")
+)
+
+// Codec encodes the payloads of the messages in a content type
+type Codec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes the data into v, a pointer to the payload
+	Unmarshal(data []byte, v any) error
+}
+
+// ProtoPayload is a payload that the protobuf codecs encode as a message of proto/events.proto,
+// the payloads that are protobuf messages themselves are encoded as they are
+type ProtoPayload interface {
+	ToProto() proto.Message
+}
+
+// protoPayloadDecoder is the pointer to a ProtoPayload, it takes the decoded message back
+type protoPayloadDecoder interface {
+	FromProto(proto.Message) error
+}
+
+var (
+	JSONCodec      Codec = jsonCodec{}
+	ProtoJSONCodec Codec = protoCodec{
+		contentType: ContentTypeProtoJSON,
+		marshal:     protojson.Marshal,
+		unmarshal:   protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal,
+	}
+	ProtobufCodec Codec = protoCodec{
+		contentType: ContentTypeProtobuf,
+		marshal:     proto.Marshal,
+		unmarshal:   proto.Unmarshal,
+	}
+)
+
+var codecs = map[string]Codec{
+	ContentTypeJSON:      JSONCodec,
+	ContentTypeProtoJSON: ProtoJSONCodec,
+	ContentTypeProtobuf:  ProtobufCodec,
+}
+
+// CodecFor is the codec of the content type, data without one is JSON
+func CodecFor(contentType string) (Codec, error) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return JSONCodec, nil
+	}
+
+	codec, ok := codecs[mediaType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+	return codec, nil
+}
+
+// codecByName reads the codec the services publish with, see MESSAGE_CODEC
+func codecByName(name string) Codec {
+	switch name {
+	case "protojson":
+		return ProtoJSONCodec
+	case "protobuf":
+		return ProtobufCodec
+	default:
+		return JSONCodec
+	}
+}
+
+// supportsPayload tells the codec encodes the payload, the JSON codec encodes any of them
+func supportsPayload(codec Codec, payload any) bool {
+	if codec == JSONCodec {
+		return true
+	}
+	switch payload.(type) {
+	case proto.Message, ProtoPayload:
+		return true
+	}
+	return false
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// protoCodec encodes the payloads through their protobuf message, in either protobuf encoding
+type protoCodec struct {
+	contentType string
+	marshal     func(proto.Message) ([]byte, error)
+	unmarshal   func([]byte, proto.Message) error
+}
+
+func (c protoCodec) ContentType() string {
+	return c.contentType
+}
+
+func (c protoCodec) Marshal(v any) ([]byte, error) {
+	switch payload := v.(type) {
+	case proto.Message:
+		return c.marshal(payload)
+	case ProtoPayload:
+		return c.marshal(payload.ToProto())
+	}
+	return nil, fmt.Errorf("%w: %T", ErrNotProtoPayload, v)
+}
+
+func (c protoCodec) Unmarshal(data []byte, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("cannot unmarshal into %T", v)
+	}
+
+	switch payload := target.Elem().Interface().(type) {
+	case proto.Message:
+		// the payload is a pointer to the message, e.g. *pb.Trip
+		msg := payload.ProtoReflect().Type().New().Interface()
+		if err := c.unmarshal(data, msg); err != nil {
+			return err
+		}
+		target.Elem().Set(reflect.ValueOf(msg))
+		return nil
+
+	case ProtoPayload:
+		decoder, ok := v.(protoPayloadDecoder)
+		if !ok {
+			break
+		}
+		msg := payload.ToProto().ProtoReflect().Type().New().Interface()
+		if err := c.unmarshal(data, msg); err != nil {
+			return err
+		}
+		return decoder.FromProto(msg)
+	}
+
+	return fmt.Errorf("%w: %T", ErrNotProtoPayload, target.Elem().Interface())
+}
@@ -23,7 +23,8 @@ type connWrapper struct {
 }
 
 type ConnectionManager struct {
-	connections map[string]*connWrapper // Local connections storage (userId -> connection)
+	// Local connections storage (userId -> connections), a user may have a few open at once, see WS_MAX_CONNECTIONS_PER_USER
+	connections map[string]map[*websocket.Conn]*connWrapper
 	mutex       sync.RWMutex
 }
 
@@ -36,7 +37,7 @@ var upgrader = websocket.Upgrader{
 // Note that on multiple instances of the API gateway, the connection manager needs to store the connections on a separate shared storage.
 func NewConnectionManager() *ConnectionManager {
 	return &ConnectionManager{
-		connections: make(map[string]*connWrapper),
+		connections: make(map[string]map[*websocket.Conn]*connWrapper),
 	}
 }
 
@@ -51,7 +52,11 @@ func (cm *ConnectionManager) Upgrade(w http.ResponseWriter, r *http.Request) (*w
 func (cm *ConnectionManager) Add(id string, conn *websocket.Conn) {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
-	cm.connections[id] = &connWrapper{
+
+	if cm.connections[id] == nil {
+		cm.connections[id] = make(map[*websocket.Conn]*connWrapper)
+	}
+	cm.connections[id][conn] = &connWrapper{
 		conn:  conn,
 		mutex: sync.Mutex{},
 	}
@@ -59,33 +64,38 @@ func (cm *ConnectionManager) Add(id string, conn *websocket.Conn) {
 	log.Printf("Added connection for user %s", id)
 }
 
-func (cm *ConnectionManager) Remove(id string) {
+// Remove forgets the connection, the other connections of the user keep receiving their messages
+func (cm *ConnectionManager) Remove(id string, conn *websocket.Conn) {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
-	delete(cm.connections, id)
-}
 
-func (cm *ConnectionManager) Get(id string) (*websocket.Conn, bool) {
-	cm.mutex.RLock()
-	defer cm.mutex.RUnlock()
-	wrapper, exists := cm.connections[id]
-	if !exists {
-		return nil, false
+	delete(cm.connections[id], conn)
+	if len(cm.connections[id]) == 0 {
+		delete(cm.connections, id)
 	}
-	return wrapper.conn, true
 }
 
+// SendMessage writes the message to every connection of the user
 func (cm *ConnectionManager) SendMessage(id string, message contracts.WSMessage) error {
 	cm.mutex.RLock()
-	wrapper, exists := cm.connections[id]
+	wrappers := make([]*connWrapper, 0, len(cm.connections[id]))
+	for _, wrapper := range cm.connections[id] {
+		wrappers = append(wrappers, wrapper)
+	}
 	cm.mutex.RUnlock()
 
-	if !exists {
+	if len(wrappers) == 0 {
 		return ErrConnectionNotFound
 	}
 
-	wrapper.mutex.Lock()
-	defer wrapper.mutex.Unlock()
+	var errs []error
+	for _, wrapper := range wrappers {
+		wrapper.mutex.Lock()
+		if err := wrapper.conn.WriteJSON(message); err != nil {
+			errs = append(errs, err)
+		}
+		wrapper.mutex.Unlock()
+	}
 
-	return wrapper.conn.WriteJSON(message)
+	return errors.Join(errs...)
 }
@@ -0,0 +1,307 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/tenteedee/mini-uber/shared/env"
+)
+
+// ConsumerConfig sets how many messages a consumer takes from its queue at once and how it handles them
+type ConsumerConfig struct {
+	// Prefetch is the number of unacked messages the broker delivers to the consumer, at least Workers
+	Prefetch int
+	// Workers handle the messages concurrently
+	Workers int
+	// OrderingKey gives the messages that must be handled one after the other the same key, they
+	// always go to the same worker. Messages without a key go to any of them. Retried messages
+	// come back after the ones that followed them, whatever their key.
+	OrderingKey func(amqp.Delivery) string
+}
+
+// DefaultConsumerConfig handles the messages of a trip in order
+func DefaultConsumerConfig() ConsumerConfig {
+	return ConsumerConfig{
+		Prefetch:    env.GetInt("RABBITMQ_PREFETCH", 10),
+		Workers:     env.GetInt("RABBITMQ_CONSUMER_WORKERS", 4),
+		OrderingKey: TripIDKey,
+	}
+}
+
+func (cfg ConsumerConfig) normalize() ConsumerConfig {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.Prefetch < cfg.Workers {
+		cfg.Prefetch = cfg.Workers
+	}
+	return cfg
+}
+
+// TripIDKey is the trip id of the message data, either its tripId or the id of its trip
+func TripIDKey(d amqp.Delivery) string {
+	envelope, err := decodeDelivery(d)
+	if err != nil {
+		return ""
+	}
+
+	body := envelope.Data
+	if codec, err := CodecFor(envelope.DataContentType); err != nil || codec != JSONCodec {
+		// any other data is read into its payload, whose JSON has the same fields
+		payload, err := decodePayload(d.RoutingKey, envelope)
+		if err != nil {
+			return ""
+		}
+		if body, err = json.Marshal(payload); err != nil {
+			return ""
+		}
+	}
+	if len(body) == 0 {
+		return ""
+	}
+
+	var data struct {
+		TripID string `json:"tripId"`
+		Trip   *struct {
+			ID string `json:"id"`
+		} `json:"trip"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return ""
+	}
+
+	if data.TripID != "" {
+		return data.TripID
+	}
+	if data.Trip != nil {
+		return data.Trip.ID
+	}
+	return ""
+}
+
+// consumer is a registered queue consumer, started again on a new channel of every new connection
+type consumer struct {
+	tag       string
+	queueName string
+	autoAck   bool
+	// retries declares the retry queues of the queue along with the consumer
+	retries bool
+	config  ConsumerConfig
+	handle  func(amqp.Delivery)
+
+	// channel is the one the consumer currently runs on, guarded by the mutex of the RabbitMQ
+	channel   *amqp.Channel
+	cancelled atomic.Bool
+}
+
+var consumerSeq atomic.Uint64
+
+// consume registers the consumer and starts it on the current connection. While reconnecting it
+// is only registered, the supervisor starts it once the connection is back.
+func (r *RabbitMQ) consume(queueName string, autoAck, retries bool, cfg ConsumerConfig, handle func(amqp.Delivery)) (*consumer, error) {
+	c := &consumer{
+		tag:       fmt.Sprintf("%s-%d", queueName, consumerSeq.Add(1)),
+		queueName: queueName,
+		autoAck:   autoAck,
+		retries:   retries,
+		config:    cfg.normalize(),
+		handle:    handle,
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.draining {
+		return nil, ErrNotConnected
+	}
+
+	if r.state == StateConnected {
+		if err := r.startConsumer(r.session, c); err != nil {
+			return nil, err
+		}
+	}
+
+	r.consumers = append(r.consumers, c)
+	return c, nil
+}
+
+// cancel stops the consumer for good, it isn't restarted on reconnection anymore. The messages
+// it already received are still handled.
+func (r *RabbitMQ) cancel(c *consumer) {
+	r.mutex.Lock()
+	for i, registered := range r.consumers {
+		if registered == c {
+			r.consumers = append(r.consumers[:i], r.consumers[i+1:]...)
+			break
+		}
+	}
+	ch, state := c.channel, r.state
+	r.mutex.Unlock()
+
+	c.cancelled.Store(true)
+
+	if state == StateConnected && ch != nil {
+		if err := ch.Cancel(c.tag, false); err != nil {
+			log.Printf("failed to cancel consumer %s: %v", c.tag, err)
+		}
+	}
+}
+
+// startConsumer opens the channel of the consumer, its prefetch doesn't hold back the other consumers.
+// It must be called with the mutex held.
+func (r *RabbitMQ) startConsumer(s *session, c *consumer) error {
+	ch, err := s.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open the channel of consumer %s: %w", c.tag, err)
+	}
+
+	if err := ch.Qos(
+		c.config.Prefetch, // prefetchCount
+		0,                 // prefetchSize: No specific limit on message size
+		false,             // global: Apply prefetchCount to each consumer individually
+	); err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to set QoS: %w", err)
+	}
+
+	if c.retries {
+		if err := declareRetryQueues(ch, c.queueName); err != nil {
+			ch.Close()
+			return err
+		}
+	}
+
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
+	msgs, err := ch.Consume(
+		c.queueName, // queue
+		c.tag,       // consumer
+		c.autoAck,   // auto-ack
+		false,       // exclusive
+		false,       // no-local
+		false,       // no-wait
+		nil,         // args
+	)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to register a consumer: %w", err)
+	}
+
+	c.channel = ch
+	r.running.Add(1)
+
+	go func() {
+		defer r.running.Done()
+
+		c.dispatch(msgs)
+
+		select {
+		case reason := <-closed:
+			// the broker closed the channel, not the connection: start over from a new connection
+			if reason != nil && !c.cancelled.Load() {
+				log.Printf("channel of consumer %s closed (%v), reconnecting", c.tag, reason)
+				s.conn.Close()
+			}
+		default:
+			// cancelled, the messages it received are handled and acked by now
+			ch.Close()
+		}
+
+		log.Printf("consumer %s stopped", c.tag)
+	}()
+
+	return nil
+}
+
+// dispatch hands the messages out to the workers and returns once they handled all of them
+func (c *consumer) dispatch(msgs <-chan amqp.Delivery) {
+	if c.config.Workers == 1 {
+		for msg := range msgs {
+			c.handle(msg)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	work := func(queue <-chan amqp.Delivery) {
+		defer wg.Done()
+		for msg := range queue {
+			c.handle(msg)
+		}
+	}
+
+	// without ordering any idle worker takes the next message
+	if c.config.OrderingKey == nil {
+		wg.Add(c.config.Workers)
+		for range c.config.Workers {
+			go work(msgs)
+		}
+		wg.Wait()
+		return
+	}
+
+	queues := make([]chan amqp.Delivery, c.config.Workers)
+	wg.Add(len(queues))
+	for i := range queues {
+		queues[i] = make(chan amqp.Delivery)
+		go work(queues[i])
+	}
+
+	next := 0
+	for msg := range msgs {
+		worker := next
+		if key := c.config.OrderingKey(msg); key != "" {
+			worker = workerFor(key, len(queues))
+		} else {
+			next = (next + 1) % len(queues)
+		}
+		queues[worker] <- msg
+	}
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+}
+
+// workerFor hashes the key, so the messages of a key go to the same worker for as long as the consumer runs
+func workerFor(key string, workers int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(workers))
+}
+
+// Shutdown cancels the consumers and waits for the messages they received to be handled, then
+// closes the connection. It gives up waiting when the context is done.
+func (r *RabbitMQ) Shutdown(ctx context.Context) error {
+	r.mutex.Lock()
+	r.draining = true
+	consumers := append([]*consumer{}, r.consumers...)
+	r.mutex.Unlock()
+
+	for _, c := range consumers {
+		r.cancel(c)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		r.running.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = fmt.Errorf("consumers still handling messages: %w", ctx.Err())
+	}
+
+	r.Close()
+	return err
+}
@@ -0,0 +1,66 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Headers of the messages in the DLQ, along with OriginalExchangeHeader, OriginalRoutingKeyHeader and RetryCountHeader
+const (
+	DeathReasonHeader   = "x-death-reason"
+	OriginalQueueHeader = "x-original-queue"
+	FailedServiceHeader = "x-failed-service"
+	// FailedAtHeader is when the last attempt failed, the message Timestamp when it was first published
+	FailedAtHeader = "x-failed-at"
+	TraceIDHeader  = "x-trace-id"
+	SpanIDHeader   = "x-span-id"
+)
+
+// deadLetter publishes the message to the DLX with why and where it failed. Rejecting it would
+// dead letter it as it was delivered, without any of that.
+func (r *RabbitMQ) deadLetter(ctx context.Context, queueName string, d amqp.Delivery, cause error) error {
+	headers := amqp.Table{}
+	for key, value := range d.Headers {
+		headers[key] = value
+	}
+
+	headers[DeathReasonHeader] = cause.Error()
+	headers[OriginalExchangeHeader] = d.Exchange
+	headers[OriginalRoutingKeyHeader] = d.RoutingKey
+	headers[OriginalQueueHeader] = queueName
+	headers[RetryCountHeader] = int32(retryCount(d.Headers))
+	headers[FailedServiceHeader] = r.serviceName
+	headers[FailedAtHeader] = time.Now().UTC()
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		headers[TraceIDHeader] = spanContext.TraceID().String()
+		headers[SpanIDHeader] = spanContext.SpanID().String()
+	}
+
+	timestamp := d.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now().UTC()
+	}
+
+	msg := amqp.Publishing{
+		Headers:       headers,
+		ContentType:   d.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: d.CorrelationId,
+		MessageId:     d.MessageId,
+		Timestamp:     timestamp,
+		Type:          d.Type,
+		AppId:         d.AppId,
+		Body:          d.Body,
+	}
+
+	if err := r.publish(ctx, DeadLetterExchange, d.RoutingKey, msg); err != nil {
+		return fmt.Errorf("failed to send message %s to the DLQ: %w", d.MessageId, err)
+	}
+
+	return nil
+}
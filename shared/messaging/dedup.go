@@ -0,0 +1,149 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	// ErrMessageInProgress is returned while another delivery of the message is being handled, the
+	// message is retried and handled again if that delivery fails
+	ErrMessageInProgress = errors.New("message is already being handled")
+)
+
+const (
+	// DedupTTL is how long the handled message ids are remembered, redeliveries come well before that
+	DedupTTL = 24 * time.Hour
+	// dedupLease is how long a claim holds when its consumer is gone without releasing it. It is shorter
+	// than the RetryDelays, a message claimed by a crashed consumer is handled before it is dead lettered.
+	dedupLease = 30 * time.Second
+)
+
+// DedupStore records which messages each consumer handled
+type DedupStore interface {
+	// Claim marks the message as being handled by the consumer. It returns false when the consumer
+	// already handled it, and ErrMessageInProgress while another claim on it holds.
+	Claim(ctx context.Context, consumerName, messageID string) (bool, error)
+	// Complete marks the claimed message as handled
+	Complete(ctx context.Context, consumerName, messageID string) error
+	// Release gives up the claim, the message can be handled again
+	Release(ctx context.Context, consumerName, messageID string) error
+}
+
+// Deduplicate skips the messages the consumer already handled, AMQP may deliver a message more than once.
+// Messages without an id are always handled.
+func Deduplicate(store DedupStore, consumerName string, handler MessageHandler) MessageHandler {
+	return func(ctx context.Context, d amqp.Delivery) error {
+		if d.MessageId == "" {
+			return handler(ctx, d)
+		}
+
+		claimed, err := store.Claim(ctx, consumerName, d.MessageId)
+		if err != nil {
+			return fmt.Errorf("failed to claim message %s for %s: %w", d.MessageId, consumerName, err)
+		}
+		if !claimed {
+			log.Printf("skipping message %s, %s already handled it", d.MessageId, consumerName)
+			return nil
+		}
+
+		if err := handler(ctx, d); err != nil {
+			if releaseErr := store.Release(ctx, consumerName, d.MessageId); releaseErr != nil {
+				log.Printf("failed to release message %s for %s: %v", d.MessageId, consumerName, releaseErr)
+			}
+			return err
+		}
+
+		// the message was handled, failing to record it only risks handling it again
+		if err := store.Complete(ctx, consumerName, d.MessageId); err != nil {
+			log.Printf("failed to record message %s as handled by %s: %v", d.MessageId, consumerName, err)
+		}
+
+		return nil
+	}
+}
+
+type inmemDedupEntry struct {
+	processed bool
+	claimedAt time.Time
+	expiresAt time.Time
+}
+
+// InmemDedupStore only deduplicates the messages of a single replica, and forgets them on restart
+type InmemDedupStore struct {
+	entries   map[string]*inmemDedupEntry
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+func NewInmemDedupStore() *InmemDedupStore {
+	return &InmemDedupStore{
+		entries:   make(map[string]*inmemDedupEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *InmemDedupStore) Claim(ctx context.Context, consumerName, messageID string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	key := dedupKey(consumerName, messageID)
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		if entry.processed {
+			return false, nil
+		}
+		if now.Sub(entry.claimedAt) < dedupLease {
+			return false, ErrMessageInProgress
+		}
+	}
+
+	s.entries[key] = &inmemDedupEntry{claimedAt: now, expiresAt: now.Add(DedupTTL)}
+	return true, nil
+}
+
+func (s *InmemDedupStore) Complete(ctx context.Context, consumerName, messageID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.entries[dedupKey(consumerName, messageID)] = &inmemDedupEntry{processed: true, claimedAt: now, expiresAt: now.Add(DedupTTL)}
+	return nil
+}
+
+func (s *InmemDedupStore) Release(ctx context.Context, consumerName, messageID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := dedupKey(consumerName, messageID)
+	if entry, ok := s.entries[key]; ok && !entry.processed {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// sweep drops the expired entries, at most once a minute
+func (s *InmemDedupStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func dedupKey(consumerName, messageID string) string {
+	return consumerName + ":" + messageID
+}
@@ -0,0 +1,109 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tenteedee/mini-uber/shared/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	dedupStatusProcessing = "processing"
+	dedupStatusProcessed  = "processed"
+)
+
+type processedMessage struct {
+	ID        string    `bson:"_id"`
+	Consumer  string    `bson:"consumer"`
+	MessageID string    `bson:"messageId"`
+	Status    string    `bson:"status"`
+	ClaimedAt time.Time `bson:"claimedAt"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// MongoDedupStore shares the handled messages between the replicas of a service, MongoDB removes
+// them once they expire
+type MongoDedupStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDedupStore(ctx context.Context, database *mongo.Database) (*MongoDedupStore, error) {
+	collection := database.Collection(db.ProcessedMessagesCollection)
+
+	if _, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create the expiry index of %s: %w", db.ProcessedMessagesCollection, err)
+	}
+
+	return &MongoDedupStore{collection: collection}, nil
+}
+
+func (s *MongoDedupStore) Claim(ctx context.Context, consumerName, messageID string) (bool, error) {
+	key := dedupKey(consumerName, messageID)
+	now := time.Now()
+
+	// inserts the claim, or takes over one whose lease ran out. Any other existing document doesn't
+	// match and the upsert fails on its id.
+	_, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": key, "status": dedupStatusProcessing, "claimedAt": bson.M{"$lt": now.Add(-dedupLease)}},
+		bson.M{"$set": bson.M{
+			"consumer":  consumerName,
+			"messageId": messageID,
+			"status":    dedupStatusProcessing,
+			"claimedAt": now,
+			"expiresAt": now.Add(DedupTTL),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err == nil {
+		return true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+
+	var existing processedMessage
+	if err := s.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&existing); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// released or expired meanwhile
+			return false, ErrMessageInProgress
+		}
+		return false, err
+	}
+
+	if existing.Status == dedupStatusProcessed {
+		return false, nil
+	}
+	return false, ErrMessageInProgress
+}
+
+func (s *MongoDedupStore) Complete(ctx context.Context, consumerName, messageID string) error {
+	now := time.Now()
+
+	_, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": dedupKey(consumerName, messageID)},
+		bson.M{"$set": bson.M{
+			"consumer":  consumerName,
+			"messageId": messageID,
+			"status":    dedupStatusProcessed,
+			"expiresAt": now.Add(DedupTTL),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *MongoDedupStore) Release(ctx context.Context, consumerName, messageID string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{
+		"_id":    dedupKey(consumerName, messageID),
+		"status": dedupStatusProcessing,
+	})
+	return err
+}
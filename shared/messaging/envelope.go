@@ -0,0 +1,112 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/tracing"
+)
+
+// envelopeContextKey carries the envelope of the message being handled, the messages published
+// by its handler are caused by it
+type envelopeContextKey struct{}
+
+func contextWithEnvelope(ctx context.Context, envelope *contracts.Envelope) context.Context {
+	return context.WithValue(ctx, envelopeContextKey{}, envelope)
+}
+
+// EnvelopeFromContext is the envelope of the message the handler was given the context for
+func EnvelopeFromContext(ctx context.Context) (*contracts.Envelope, bool) {
+	envelope, ok := ctx.Value(envelopeContextKey{}).(*contracts.Envelope)
+	return envelope, ok
+}
+
+// decodeDelivery reads the envelope of the delivery in either content mode, a legacy one takes its id from the message
+func decodeDelivery(d amqp.Delivery) (*contracts.Envelope, error) {
+	envelope, err := contracts.DecodeMessage(d.ContentType, d.Headers, d.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if envelope.IsLegacy() {
+		envelope.ID = d.MessageId
+		envelope.Type = d.RoutingKey
+		envelope.CorrelationID = d.CorrelationId
+	}
+
+	return envelope, nil
+}
+
+// publishEvent wraps the data, encoded by the codec, in the envelope with the message being handled as its
+// cause. JSON data goes in the JSON envelope, any other data is the body of the message with the attributes
+// of the envelope in its headers: the consumers pick the codec by the content type of the message.
+func (r *RabbitMQ) publishEvent(ctx context.Context, routingKey, dataSchema, ownerID string, codec Codec, data []byte) error {
+	log.Printf("publishing message with routing key: %s", routingKey)
+
+	id := uuid.NewString()
+	now := time.Now().UTC()
+
+	// a message published outside of any handler starts a new flow
+	correlationID, causationID := id, ""
+	if cause, ok := EnvelopeFromContext(ctx); ok && cause.ID != "" {
+		causationID = cause.ID
+		correlationID = cause.CorrelationID
+		if correlationID == "" {
+			correlationID = cause.ID
+		}
+	}
+
+	envelope := contracts.Envelope{
+		SpecVersion:     contracts.CloudEventsSpecVersion,
+		ID:              id,
+		Source:          "/" + r.serviceName,
+		Type:            routingKey,
+		Time:            now,
+		DataContentType: codec.ContentType(),
+		DataSchema:      dataSchema,
+		CorrelationID:   correlationID,
+		CausationID:     causationID,
+		OwnerID:         ownerID,
+	}
+
+	var body []byte
+	var headers amqp.Table
+	var err error
+	contentType := contracts.CloudEventsContentType
+	switch {
+	case codec != JSONCodec:
+		contentType = codec.ContentType()
+		headers = amqp.Table(envelope.Headers())
+		body = data
+	case r.legacyEnvelope:
+		contentType = ContentTypeJSON
+		body, err = json.Marshal(contracts.AmqpMessage{OwnerID: ownerID, Data: data})
+	default:
+		envelope.Data = data
+		body, err = json.Marshal(envelope)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	msg := amqp.Publishing{
+		Headers:      headers,
+		DeliveryMode: amqp.Persistent,
+		ContentType:  contentType,
+		// consumers tell redeliveries apart by the id, retries and replays of the message keep it
+		MessageId:     id,
+		CorrelationId: correlationID,
+		Timestamp:     now,
+		Type:          routingKey,
+		AppId:         r.serviceName,
+		Body:          body,
+	}
+
+	return tracing.TracedPublisher(ctx, TripExchange, routingKey, msg, r.publish)
+}
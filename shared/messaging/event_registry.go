@@ -0,0 +1,181 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/tenteedee/mini-uber/shared/contracts"
+)
+
+var (
+	// ErrSchemaMismatch is returned when the data of a message doesn't decode into the payload of its
+	// event. It won't decode any better later on, the message is dead lettered without being retried.
+	ErrSchemaMismatch = errors.New("message doesn't match the schema of its event")
+	// ErrUnknownEvent is returned for a message no handler subscribed to
+	ErrUnknownEvent = errors.New("no handler subscribed to the event")
+)
+
+// Event binds a routing key to the type of its payload, see events.go for the events of the trip exchange
+type Event[T any] struct {
+	routingKey string
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]reflect.Type{}
+)
+
+// NewEvent registers the payload type of the routing key, it panics when the key was registered with another type
+func NewEvent[T any](routingKey string) Event[T] {
+	payloadType := reflect.TypeFor[T]()
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if registered, ok := registry[routingKey]; ok && registered != payloadType {
+		panic(fmt.Sprintf("event %s registered with payload %s and %s", routingKey, registered, payloadType))
+	}
+	registry[routingKey] = payloadType
+
+	return Event[T]{routingKey: routingKey}
+}
+
+func (e Event[T]) RoutingKey() string {
+	return e.routingKey
+}
+
+// PayloadType is the payload registered for the routing key
+func PayloadType(routingKey string) (reflect.Type, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	payloadType, ok := registry[routingKey]
+	return payloadType, ok
+}
+
+// Message is a decoded message of an event
+type Message[T any] struct {
+	RoutingKey string
+	OwnerID    string
+	Data       T
+}
+
+// DataSchema names the payload of the event in the envelope, a payload changing in a way its consumers
+// can't read is published as a new version
+func (e Event[T]) DataSchema() string {
+	return fmt.Sprintf("urn:mini-uber:%s:v%d", e.routingKey, eventSchemaVersion)
+}
+
+const eventSchemaVersion = 1
+
+// Publish encodes the payload with the codec of the publisher, wraps it in the envelope of the messages and
+// publishes it on the routing key of the event. A payload without a protobuf message is published as JSON,
+// as are all of them while the legacy envelope is published.
+func Publish[T any](ctx context.Context, r *RabbitMQ, event Event[T], ownerID string, data T) error {
+	codec := r.codec
+	if r.legacyEnvelope || !supportsPayload(codec, data) {
+		codec = JSONCodec
+	}
+
+	payload, err := codec.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s payload: %w", event.routingKey, err)
+	}
+
+	return r.publishEvent(ctx, event.routingKey, event.DataSchema(), ownerID, codec, payload)
+}
+
+// Decode unwraps the message of the event from any envelope, with the codec of its content type.
+// A JSON message without data decodes into the zero payload.
+func Decode[T any](event Event[T], d amqp.Delivery) (Message[T], error) {
+	msg := Message[T]{RoutingKey: d.RoutingKey}
+
+	envelope, err := decodeDelivery(d)
+	if err != nil {
+		return msg, fmt.Errorf("%w: %s: %v", ErrSchemaMismatch, event.routingKey, err)
+	}
+	msg.OwnerID = envelope.OwnerID
+
+	if err := decodeData(envelope, &msg.Data); err != nil {
+		return msg, fmt.Errorf("%w: %s into %T: %v", ErrSchemaMismatch, event.routingKey, msg.Data, err)
+	}
+
+	return msg, nil
+}
+
+// decodeData decodes the data of the envelope into v with the codec of its content type
+func decodeData(envelope *contracts.Envelope, v any) error {
+	codec, err := CodecFor(envelope.DataContentType)
+	if err != nil {
+		return err
+	}
+
+	// an empty protobuf message has no bytes, only JSON tells no data apart
+	data := envelope.Payload()
+	if len(data) == 0 && codec == JSONCodec {
+		return nil
+	}
+
+	return codec.Unmarshal(data, v)
+}
+
+// DecodePayload decodes the data of the delivery into the payload registered for its routing key, for
+// the consumers that handle the messages of any event
+func DecodePayload(d amqp.Delivery) (any, error) {
+	envelope, err := decodeDelivery(d)
+	if err != nil {
+		return nil, err
+	}
+	return decodePayload(d.RoutingKey, envelope)
+}
+
+func decodePayload(routingKey string, envelope *contracts.Envelope) (any, error) {
+	payloadType, ok := PayloadType(routingKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEvent, routingKey)
+	}
+
+	payload := reflect.New(payloadType)
+	if err := decodeData(envelope, payload.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: %s into %s: %v", ErrSchemaMismatch, routingKey, payloadType, err)
+	}
+
+	return payload.Elem().Interface(), nil
+}
+
+// Subscriptions dispatch the messages of a queue to the handler subscribed to their routing key
+type Subscriptions struct {
+	handlers map[string]MessageHandler
+}
+
+func NewSubscriptions() *Subscriptions {
+	return &Subscriptions{
+		handlers: make(map[string]MessageHandler),
+	}
+}
+
+// Subscribe handles the messages of the event with the decoded payload
+func Subscribe[T any](s *Subscriptions, event Event[T], handler func(context.Context, Message[T]) error) {
+	s.handlers[event.routingKey] = func(ctx context.Context, d amqp.Delivery) error {
+		msg, err := Decode(event, d)
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+		return handler(ctx, msg)
+	}
+}
+
+// Handle is the MessageHandler of the queue
+func (s *Subscriptions) Handle(ctx context.Context, d amqp.Delivery) error {
+	handler, ok := s.handlers[d.RoutingKey]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEvent, d.RoutingKey)
+	}
+	return handler(ctx, d)
+}
@@ -1,23 +1,59 @@
 package messaging
 
 import (
+	"github.com/tenteedee/mini-uber/shared/contracts"
 	pbd "github.com/tenteedee/mini-uber/shared/proto/driver"
 	pb "github.com/tenteedee/mini-uber/shared/proto/trip"
+	"github.com/tenteedee/mini-uber/shared/types"
 )
 
 const (
 	FindAvailableDriversQueue        = "find_available_drivers"
 	DriverCmdTripRequestQueue        = "driver_cmd_trip_request"
 	DriverTripResponseQueue          = "driver_trip_response"
+	TripDriverOfferQueue             = "trip_driver_offer"
 	NotifyDriversNoDriversFoundQueue = "notify_drivers_no_drivers_found"
 	NotifyDriverAssignQueue          = "notify_driver_assign"
 	PaymentTripResponseQueue         = "payment_trip_response"
 	NotifyPaymentSessionCreatedQueue = "notify_payment_session_created"
-	NotifyPaymentSuccessQueue        = "payment_success"
+	PaymentStatusQueue               = "payment_status"
+	NotifyPaymentStatusQueue         = "notify_payment_status"
+	NotifyTripCompletedQueue         = "notify_trip_completed"
+	NotifyDriverTipQueue             = "notify_driver_tip"
 )
 
 const DeadLetterQueue = "dead_letter_queue"
 
+// Events of the trip exchange with their payloads
+var (
+	TripEventCreated = NewEvent[TripEventData](contracts.TripEventCreated)
+	// the rider's app takes the assigned driver from the trip itself
+	TripEventDriverAssigned      = NewEvent[*pb.Trip](contracts.TripEventDriverAssigned)
+	TripEventNoDriversFound      = NewEvent[NoData](contracts.TripEventNoDriversFound)
+	TripEventDriverNotInterested = NewEvent[TripEventData](contracts.TripEventDriverNotInterested)
+	TripEventCompleted           = NewEvent[TripEventData](contracts.TripEventCompleted)
+
+	DriverCmdTripRequest  = NewEvent[TripEventData](contracts.DriverCmdTripRequest)
+	DriverCmdTripAccept   = NewEvent[DriverTripResponseData](contracts.DriverCmdTripAccept)
+	DriverCmdTripDecline  = NewEvent[DriverTripResponseData](contracts.DriverCmdTripDecline)
+	DriverCmdTripComplete = NewEvent[DriverTripCompleteData](contracts.DriverCmdTripComplete)
+
+	PaymentEventSessionCreated  = NewEvent[PaymentEventSessionCreatedData](contracts.PaymentEventSessionCreated)
+	PaymentEventSuccess         = NewEvent[PaymentStatusUpdateData](contracts.PaymentEventSuccess)
+	PaymentEventFailed          = NewEvent[PaymentStatusUpdateData](contracts.PaymentEventFailed)
+	PaymentEventCancelled       = NewEvent[PaymentStatusUpdateData](contracts.PaymentEventCancelled)
+	PaymentEventRefunded        = NewEvent[PaymentRefundedData](contracts.PaymentEventRefunded)
+	PaymentEventTipReceived     = NewEvent[PaymentTipData](contracts.PaymentEventTipReceived)
+	PaymentEventTipFailed       = NewEvent[PaymentTipData](contracts.PaymentEventTipFailed)
+	PaymentEventChargeScheduled = NewEvent[PaymentChargeScheduledData](contracts.PaymentEventChargeScheduled)
+
+	PaymentCmdCreateSession = NewEvent[PaymentTripResponseData](contracts.PaymentCmdCreateSession)
+	PaymentCmdCreateTip     = NewEvent[PaymentTipData](contracts.PaymentCmdCreateTip)
+)
+
+// NoData is the payload of the events that only tell their owner something happened
+type NoData struct{}
+
 type TripEventData struct {
 	Trip *pb.Trip `json:"trip"`
 }
@@ -28,23 +64,70 @@ type DriverTripResponseData struct {
 	RiderId string      `json:"riderId"`
 }
 
+type DriverTripCompleteData struct {
+	TripID string `json:"tripId"`
+}
+
 type PaymentEventSessionCreatedData struct {
-	TripID    string  `json:"tripId"`
-	SessionID string  `json:"sessionId"`
-	Amount    float64 `json:"amount"`
-	Currency  string  `json:"currency"`
+	TripID      string      `json:"tripId"`
+	SessionID   string      `json:"sessionId"`
+	CheckoutURL string      `json:"checkoutUrl,omitempty"` // hosted checkout page, when the processor has one
+	Amount      types.Money `json:"amount"`
+	TipID       string      `json:"tipId,omitempty"` // set when the session charges a tip rather than the fare
+	// Reason is set when the fare was meant to be charged to the saved payment method, but that charge didn't go through
+	Reason string `json:"reason,omitempty"`
+}
+
+// PaymentChargeScheduledData replaces the checkout session when the rider has a saved payment method
+type PaymentChargeScheduledData struct {
+	TripID        string               `json:"tripId"`
+	UserID        string               `json:"userId"`
+	DriverID      string               `json:"driverId"`
+	Amount        types.Money          `json:"amount"`
+	PaymentMethod PaymentMethodSummary `json:"paymentMethod"`
+}
+
+// PaymentMethodSummary is what the rider is shown of a saved card
+type PaymentMethodSummary struct {
+	ID    string `json:"id"`
+	Brand string `json:"brand"`
+	Last4 string `json:"last4"`
 }
 
 type PaymentTripResponseData struct {
-	TripID   string  `json:"tripId"`
-	UserID   string  `json:"userId"`
-	DriverID string  `json:"driverId"`
-	Amount   float64 `json:"amount"`
-	Currency string  `json:"currency"`
+	TripID      string      `json:"tripId"`
+	UserID      string      `json:"userId"`
+	DriverID    string      `json:"driverId"`
+	PackageSlug string      `json:"packageSlug"`
+	Amount      types.Money `json:"amount"`
+}
+
+// PaymentTipData asks for a tip to be charged (payment.cmd.create_tip) and reports
+// its outcome (payment.event.tip_received, payment.event.tip_failed)
+type PaymentTipData struct {
+	TripID   string      `json:"tripId"`
+	TipID    string      `json:"tipId"`
+	UserID   string      `json:"userId"`
+	DriverID string      `json:"driverId"`
+	Amount   types.Money `json:"amount"`
+	Reason   string      `json:"reason,omitempty"` // why the tip could not be charged
 }
 
 type PaymentStatusUpdateData struct {
-	TripID   string `json:"tripId"`
-	UserID   string `json:"userId"`
-	DriverID string `json:"driverId"`
+	TripID    string `json:"tripId"`
+	UserID    string `json:"userId"`
+	DriverID  string `json:"driverId"`
+	SessionID string `json:"sessionId,omitempty"`
+	Reason    string `json:"reason,omitempty"` // why a payment failed or was cancelled
+}
+
+type PaymentRefundedData struct {
+	TripID         string      `json:"tripId"`
+	UserID         string      `json:"userId"`
+	PaymentID      string      `json:"paymentId"`
+	RefundID       string      `json:"refundId"`
+	Amount         types.Money `json:"amount"`
+	Reason         string      `json:"reason,omitempty"`
+	FullyRefunded  bool        `json:"fullyRefunded"`
+	RefundedAmount types.Money `json:"refundedAmount"` // total refunded on the payment so far
 }
@@ -0,0 +1,241 @@
+package messaging
+
+import (
+	"fmt"
+
+	pbe "github.com/tenteedee/mini-uber/shared/proto/events"
+	"github.com/tenteedee/mini-uber/shared/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// The payloads of events.go and their messages in proto/events.proto, for the protobuf codecs
+
+func unexpectedMessage(payload any, msg proto.Message) error {
+	return fmt.Errorf("%w: %T into %T", ErrNotProtoPayload, msg, payload)
+}
+
+func (NoData) ToProto() proto.Message {
+	return &pbe.NoData{}
+}
+
+func (d *NoData) FromProto(msg proto.Message) error {
+	if _, ok := msg.(*pbe.NoData); !ok {
+		return unexpectedMessage(d, msg)
+	}
+	return nil
+}
+
+func (d TripEventData) ToProto() proto.Message {
+	return &pbe.TripEventData{Trip: d.Trip}
+}
+
+func (d *TripEventData) FromProto(msg proto.Message) error {
+	m, ok := msg.(*pbe.TripEventData)
+	if !ok {
+		return unexpectedMessage(d, msg)
+	}
+	d.Trip = m.GetTrip()
+	return nil
+}
+
+func (d DriverTripResponseData) ToProto() proto.Message {
+	return &pbe.DriverTripResponseData{
+		Driver:  d.Driver,
+		TripId:  d.TripId,
+		RiderId: d.RiderId,
+	}
+}
+
+func (d *DriverTripResponseData) FromProto(msg proto.Message) error {
+	m, ok := msg.(*pbe.DriverTripResponseData)
+	if !ok {
+		return unexpectedMessage(d, msg)
+	}
+	*d = DriverTripResponseData{
+		Driver:  m.GetDriver(),
+		TripId:  m.GetTripId(),
+		RiderId: m.GetRiderId(),
+	}
+	return nil
+}
+
+func (d DriverTripCompleteData) ToProto() proto.Message {
+	return &pbe.DriverTripCompleteData{TripId: d.TripID}
+}
+
+func (d *DriverTripCompleteData) FromProto(msg proto.Message) error {
+	m, ok := msg.(*pbe.DriverTripCompleteData)
+	if !ok {
+		return unexpectedMessage(d, msg)
+	}
+	d.TripID = m.GetTripId()
+	return nil
+}
+
+func (d PaymentEventSessionCreatedData) ToProto() proto.Message {
+	return &pbe.PaymentEventSessionCreatedData{
+		TripId:      d.TripID,
+		SessionId:   d.SessionID,
+		CheckoutUrl: d.CheckoutURL,
+		Amount:      d.Amount.ToProto(),
+		TipId:       d.TipID,
+		Reason:      d.Reason,
+	}
+}
+
+func (d *PaymentEventSessionCreatedData) FromProto(msg proto.Message) error {
+	m, ok := msg.(*pbe.PaymentEventSessionCreatedData)
+	if !ok {
+		return unexpectedMessage(d, msg)
+	}
+	*d = PaymentEventSessionCreatedData{
+		TripID:      m.GetTripId(),
+		SessionID:   m.GetSessionId(),
+		CheckoutURL: m.GetCheckoutUrl(),
+		Amount:      types.MoneyFromProto(m.GetAmount()),
+		TipID:       m.GetTipId(),
+		Reason:      m.GetReason(),
+	}
+	return nil
+}
+
+func (d PaymentChargeScheduledData) ToProto() proto.Message {
+	return &pbe.PaymentChargeScheduledData{
+		TripId:   d.TripID,
+		UserId:   d.UserID,
+		DriverId: d.DriverID,
+		Amount:   d.Amount.ToProto(),
+		PaymentMethod: &pbe.PaymentMethodSummary{
+			Id:    d.PaymentMethod.ID,
+			Brand: d.PaymentMethod.Brand,
+			Last4: d.PaymentMethod.Last4,
+		},
+	}
+}
+
+func (d *PaymentChargeScheduledData) FromProto(msg proto.Message) error {
+	m, ok := msg.(*pbe.PaymentChargeScheduledData)
+	if !ok {
+		return unexpectedMessage(d, msg)
+	}
+	*d = PaymentChargeScheduledData{
+		TripID:   m.GetTripId(),
+		UserID:   m.GetUserId(),
+		DriverID: m.GetDriverId(),
+		Amount:   types.MoneyFromProto(m.GetAmount()),
+		PaymentMethod: PaymentMethodSummary{
+			ID:    m.GetPaymentMethod().GetId(),
+			Brand: m.GetPaymentMethod().GetBrand(),
+			Last4: m.GetPaymentMethod().GetLast4(),
+		},
+	}
+	return nil
+}
+
+func (d PaymentTripResponseData) ToProto() proto.Message {
+	return &pbe.PaymentTripResponseData{
+		TripId:      d.TripID,
+		UserId:      d.UserID,
+		DriverId:    d.DriverID,
+		PackageSlug: d.PackageSlug,
+		Amount:      d.Amount.ToProto(),
+	}
+}
+
+func (d *PaymentTripResponseData) FromProto(msg proto.Message) error {
+	m, ok := msg.(*pbe.PaymentTripResponseData)
+	if !ok {
+		return unexpectedMessage(d, msg)
+	}
+	*d = PaymentTripResponseData{
+		TripID:      m.GetTripId(),
+		UserID:      m.GetUserId(),
+		DriverID:    m.GetDriverId(),
+		PackageSlug: m.GetPackageSlug(),
+		Amount:      types.MoneyFromProto(m.GetAmount()),
+	}
+	return nil
+}
+
+func (d PaymentTipData) ToProto() proto.Message {
+	return &pbe.PaymentTipData{
+		TripId:   d.TripID,
+		TipId:    d.TipID,
+		UserId:   d.UserID,
+		DriverId: d.DriverID,
+		Amount:   d.Amount.ToProto(),
+		Reason:   d.Reason,
+	}
+}
+
+func (d *PaymentTipData) FromProto(msg proto.Message) error {
+	m, ok := msg.(*pbe.PaymentTipData)
+	if !ok {
+		return unexpectedMessage(d, msg)
+	}
+	*d = PaymentTipData{
+		TripID:   m.GetTripId(),
+		TipID:    m.GetTipId(),
+		UserID:   m.GetUserId(),
+		DriverID: m.GetDriverId(),
+		Amount:   types.MoneyFromProto(m.GetAmount()),
+		Reason:   m.GetReason(),
+	}
+	return nil
+}
+
+func (d PaymentStatusUpdateData) ToProto() proto.Message {
+	return &pbe.PaymentStatusUpdateData{
+		TripId:    d.TripID,
+		UserId:    d.UserID,
+		DriverId:  d.DriverID,
+		SessionId: d.SessionID,
+		Reason:    d.Reason,
+	}
+}
+
+func (d *PaymentStatusUpdateData) FromProto(msg proto.Message) error {
+	m, ok := msg.(*pbe.PaymentStatusUpdateData)
+	if !ok {
+		return unexpectedMessage(d, msg)
+	}
+	*d = PaymentStatusUpdateData{
+		TripID:    m.GetTripId(),
+		UserID:    m.GetUserId(),
+		DriverID:  m.GetDriverId(),
+		SessionID: m.GetSessionId(),
+		Reason:    m.GetReason(),
+	}
+	return nil
+}
+
+func (d PaymentRefundedData) ToProto() proto.Message {
+	return &pbe.PaymentRefundedData{
+		TripId:         d.TripID,
+		UserId:         d.UserID,
+		PaymentId:      d.PaymentID,
+		RefundId:       d.RefundID,
+		Amount:         d.Amount.ToProto(),
+		Reason:         d.Reason,
+		FullyRefunded:  d.FullyRefunded,
+		RefundedAmount: d.RefundedAmount.ToProto(),
+	}
+}
+
+func (d *PaymentRefundedData) FromProto(msg proto.Message) error {
+	m, ok := msg.(*pbe.PaymentRefundedData)
+	if !ok {
+		return unexpectedMessage(d, msg)
+	}
+	*d = PaymentRefundedData{
+		TripID:         m.GetTripId(),
+		UserID:         m.GetUserId(),
+		PaymentID:      m.GetPaymentId(),
+		RefundID:       m.GetRefundId(),
+		Amount:         types.MoneyFromProto(m.GetAmount()),
+		Reason:         m.GetReason(),
+		FullyRefunded:  m.GetFullyRefunded(),
+		RefundedAmount: types.MoneyFromProto(m.GetRefundedAmount()),
+	}
+	return nil
+}
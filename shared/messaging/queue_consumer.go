@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 
+	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/tenteedee/mini-uber/shared/contracts"
 )
 
@@ -11,6 +12,7 @@ type QueueConsumer struct {
 	rb        *RabbitMQ
 	connMgr   *ConnectionManager
 	queueName string
+	consumer  *consumer
 }
 
 func NewQueueConsumer(rb *RabbitMQ, connMgr *ConnectionManager, queueName string) *QueueConsumer {
@@ -21,52 +23,57 @@ func NewQueueConsumer(rb *RabbitMQ, connMgr *ConnectionManager, queueName string
 	}
 }
 
+// Start forwards the messages of the queue to the websockets of their owners until Stop is called
 func (qc *QueueConsumer) Start() error {
-	msgs, err := qc.rb.Channel.Consume(
-		qc.queueName,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+	// one worker, the messages of a user are forwarded in order
+	c, err := qc.rb.consume(qc.queueName, true, false, ConsumerConfig{Prefetch: 10, Workers: 1}, qc.forward)
 	if err != nil {
 		return err
 	}
 
-	go func() {
-		for msg := range msgs {
-			var msgBody contracts.AmqpMessage
-
-			// Unmarshal the message body
-			if err := json.Unmarshal(msg.Body, &msgBody); err != nil {
-				log.Println("Failed to unmarshal message:", err)
-				continue
-			}
+	qc.consumer = c
+	return nil
+}
 
-			userID := msgBody.OwnerID
+func (qc *QueueConsumer) Stop() {
+	if qc.consumer != nil {
+		qc.rb.cancel(qc.consumer)
+		qc.consumer = nil
+	}
+}
 
-			// Unmarshal the payload from the message body
-			var payload any
-			if msgBody.Data != nil {
-				if err := json.Unmarshal(msgBody.Data, &payload); err != nil {
-					log.Println("Failed to unmarshal payload:", err)
-					continue
-				}
-			}
+func (qc *QueueConsumer) forward(msg amqp.Delivery) {
+	// services publish either envelope while they migrate
+	envelope, err := decodeDelivery(msg)
+	if err != nil {
+		log.Println("Failed to unmarshal message:", err)
+		return
+	}
 
-			clientMsg := contracts.WSMessage{
-				Type: msg.RoutingKey,
-				Data: payload,
-			}
+	userID := envelope.OwnerID
 
-			// route the message to the appropriate user via WebSocket
-			if err := qc.connMgr.SendMessage(userID, clientMsg); err != nil {
-				log.Printf("Failed to send message to user %s: %v", userID, err)
-			}
+	// Unmarshal the payload from the message body, the websockets are sent the JSON of the payload
+	// whatever the codec it was published with
+	var payload any
+	if codec, err := CodecFor(envelope.DataContentType); err != nil || codec != JSONCodec {
+		if payload, err = decodePayload(msg.RoutingKey, envelope); err != nil {
+			log.Println("Failed to unmarshal payload:", err)
+			return
 		}
-	}()
+	} else if envelope.Data != nil {
+		if err := json.Unmarshal(envelope.Data, &payload); err != nil {
+			log.Println("Failed to unmarshal payload:", err)
+			return
+		}
+	}
 
-	return nil
+	clientMsg := contracts.WSMessage{
+		Type: msg.RoutingKey,
+		Data: payload,
+	}
+
+	// route the message to the appropriate user via WebSocket
+	if err := qc.connMgr.SendMessage(userID, clientMsg); err != nil {
+		log.Printf("Failed to send message to user %s: %v", userID, err)
+	}
 }
@@ -2,12 +2,16 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/env"
 	"github.com/tenteedee/mini-uber/shared/retry"
 	"github.com/tenteedee/mini-uber/shared/tracing"
 )
@@ -17,137 +21,221 @@ const (
 	DeadLetterExchange = "dlx"
 )
 
+var (
+	ErrNotConnected = errors.New("not connected to RabbitMQ")
+	// ErrNotConfirmed is returned when the broker nacked the message, or didn't confirm it in time
+	ErrNotConfirmed = errors.New("message not confirmed by RabbitMQ")
+	// ErrUnroutable is returned when no queue is bound to the routing key of the message
+	ErrUnroutable = errors.New("message not routed to any queue")
+)
+
+// RabbitMQ is the connection shared by a service. It is supervised: when the broker goes away
+// the connection is re-established, the topology declared again and the consumers restarted.
 type RabbitMQ struct {
+	uri string
+	// serviceName is recorded on the messages the service sends to the DLQ
+	serviceName    string
+	reconnect      retry.Config
+	confirmTimeout time.Duration
+	// legacyEnvelope publishes AmqpMessage rather than Envelope, until every consumer reads the Envelope
+	legacyEnvelope bool
+	// codec encodes the payloads of the events, the consumers read the messages in any codec by their content type
+	codec Codec
+
+	mutex     sync.RWMutex
+	session   *session
+	state     ConnectionState
+	consumers []*consumer
+	listeners []func(ConnectionState)
+	// draining is set by Shutdown, no consumer is started anymore
+	draining bool
+	// running counts the consumers still handling the messages they received
+	running sync.WaitGroup
+
+	// publishes wait for their confirmation one at a time, so a returned message is the one being published
+	publishMutex sync.Mutex
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// session is a connection to the broker with its channels, replaced as a whole on reconnection
+type session struct {
 	conn    *amqp.Connection
-	Channel *amqp.Channel
+	channel *amqp.Channel
+	// publishChannel is in confirm mode, its unroutable messages come back on returns
+	publishChannel *amqp.Channel
+	returns        chan amqp.Return
 }
 
-func NewRabbitMQ(uri string) (*RabbitMQ, error) {
-	conn, err := amqp.Dial(uri)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+func NewRabbitMQ(uri string, serviceName string) (*RabbitMQ, error) {
+	rmq := &RabbitMQ{
+		uri:            uri,
+		serviceName:    serviceName,
+		reconnect:      retry.Config{InitialWait: time.Second, MaxWait: 30 * time.Second},
+		confirmTimeout: time.Duration(env.GetInt("RABBITMQ_CONFIRM_TIMEOUT_MS", 5000)) * time.Millisecond,
+		legacyEnvelope: env.GetString("MESSAGE_ENVELOPE", "cloudevents") == "legacy",
+		codec:          codecByName(env.GetString("MESSAGE_CODEC", "json")),
+		done:           make(chan struct{}),
 	}
 
-	ch, err := conn.Channel()
+	s, err := rmq.dial()
 	if err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("failed to open a channel: %v", err)
+		return nil, err
 	}
 
-	rmq := &RabbitMQ{
-		conn:    conn,
-		Channel: ch,
-	}
+	rmq.session = s
+	rmq.state = StateConnected
 
-	if err := rmq.setupExchangesAndQueues(); err != nil {
-		rmq.Close()
-		return nil, fmt.Errorf("failed to set up exchanges and queues: %v", err)
-	}
+	go rmq.supervise()
 
 	return rmq, nil
 }
 
+// PublishMessage returns once the broker confirmed the message, it fails with ErrUnroutable when
+// no queue is bound to the routing key and with ErrNotConfirmed when the broker didn't take it.
+// Publish checks the data against the payload of the event, prefer it.
 func (r *RabbitMQ) PublishMessage(ctx context.Context, routingKey string, message contracts.AmqpMessage) error {
-	log.Printf("publishing message with routing key: %s", routingKey)
-
-	jsonMessage, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %v", err)
-	}
-
-	msg := amqp.Publishing{
-		DeliveryMode: amqp.Persistent,
-		ContentType:  "application/json",
-		Body:         jsonMessage,
-	}
-
-	return tracing.TracedPublisher(ctx, TripExchange, routingKey, msg, r.publish)
-
+	return r.publishEvent(ctx, routingKey, "", message.OwnerID, JSONCodec, message.Data)
 }
 
 type MessageHandler func(context.Context, amqp.Delivery) error
 
-func (r *RabbitMQ) ConsumeMessages(queueName string, handler MessageHandler) error {
-	err := r.Channel.Qos(
-		1,     // prefetchCount: Limit to 1 unacknowledged message per consumer
-		0,     // prefetchSize: No specific limit on message size
-		false, // global: Apply prefetchCount to each consumer individually
-	)
-	if err != nil {
-		return fmt.Errorf("failed to set QoS: %v", err)
-	}
+// ConsumeMessages acks the messages the handler processed. A failed message is delivered again after
+// each of the RetryDelays, waiting in the retry queues so the next messages are handled meanwhile,
+// then sent to the DLQ along with why it failed. Messages that don't match the schema of their event go
+// to the DLQ right away. The consumer keeps running across reconnections.
+func (r *RabbitMQ) ConsumeMessages(queueName string, cfg ConsumerConfig, handler MessageHandler) error {
+	_, err := r.consume(queueName, false, true, cfg, func(msg amqp.Delivery) {
+		restoreOrigin(&msg)
 
-	msgs, err := r.Channel.Consume(
-		queueName, // queue
-		"",        // consumer
-		false,     // auto-ack
-		false,     // exclusive
-		false,     // no-local
-		false,     // no-wait
-		nil,       // args
-	)
-	if err != nil {
-		return fmt.Errorf("failed to register a consumer: %w", err)
-	}
+		if err := tracing.TracedConsumer(msg, func(ctx context.Context, d amqp.Delivery) error {
+			log.Printf("receive message: %s", msg.Body)
 
-	go func() {
-		for msg := range msgs {
-			if err := tracing.TracedConsumer(msg, func(ctx context.Context, d amqp.Delivery) error {
-				log.Printf("receive message: %s", msg.Body)
-
-				cfg := retry.DefaultConfig()
-				err := retry.WithBackoff(ctx, cfg, func() error {
-					return handler(ctx, d)
-				})
-				if err != nil {
-					log.Printf("message handling failed after %v retries for message Id: %s, error: %v", cfg.MaxRetries, d.MessageId, err)
-
-					// add failure context before sending to DLQ
-					headers := amqp.Table{}
-					if d.Headers != nil {
-						headers = d.Headers
-					}
-
-					headers["x-death-reason"] = err.Error()
-					headers["x-origin-exchange"] = d.Exchange
-					headers["x-original-routing-key"] = d.RoutingKey
-					headers["x-retry-count"] = cfg.MaxRetries
-					d.Headers = headers
-
-					// reject the message without requeueing to send it to the DLQ
-					_ = d.Reject(false)
-					return err
-				}
+			if envelope, err := decodeDelivery(d); err == nil {
+				ctx = contextWithEnvelope(ctx, envelope)
+			}
 
+			err := handler(ctx, d)
+			if err == nil {
 				// ack the message if the handler succeeded
 				if ackErr := msg.Ack(false); ackErr != nil {
 					log.Printf("Failed to Ack message: %v. Message body: %s", ackErr, msg.Body)
 				}
-
 				return nil
-			}); err != nil {
-				log.Printf("error processing message: %v", err)
 			}
+
+			var retried bool
+			var retryErr error
+			if !errors.Is(err, ErrSchemaMismatch) {
+				retried, retryErr = r.scheduleRetry(ctx, queueName, d)
+			}
+			if retryErr != nil {
+				// the message is delivered again right away rather than lost
+				log.Printf("%v, requeueing it", retryErr)
+				_ = d.Nack(false, true)
+				return err
+			}
+			if retried {
+				log.Printf("message handling failed for message Id: %s, retry %d scheduled: %v", d.MessageId, retryCount(d.Headers)+1, err)
+				if ackErr := d.Ack(false); ackErr != nil {
+					log.Printf("Failed to Ack message: %v. Message body: %s", ackErr, msg.Body)
+				}
+				return err
+			}
+
+			log.Printf("message handling failed after %v retries for message Id: %s, error: %v", retryCount(d.Headers), d.MessageId, err)
+
+			if dlqErr := r.deadLetter(ctx, queueName, d, err); dlqErr != nil {
+				log.Printf("%v, requeueing it", dlqErr)
+				_ = d.Nack(false, true)
+				return err
+			}
+
+			if ackErr := d.Ack(false); ackErr != nil {
+				log.Printf("Failed to Ack message: %v. Message body: %s", ackErr, msg.Body)
+			}
+			return err
+		}); err != nil {
+			log.Printf("error processing message: %v", err)
 		}
-	}()
+	})
 
-	return nil
+	return err
 }
 
 func (r *RabbitMQ) publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
-	return r.Channel.PublishWithContext(
+	r.mutex.RLock()
+	s, state := r.session, r.state
+	r.mutex.RUnlock()
+
+	if state != StateConnected {
+		return ErrNotConnected
+	}
+
+	// returned messages are told apart by their id
+	if msg.MessageId == "" {
+		msg.MessageId = uuid.NewString()
+	}
+
+	r.publishMutex.Lock()
+	defer r.publishMutex.Unlock()
+
+	// returns of messages whose confirmation timed out must not be taken for this one's
+	s.takeReturn("")
+
+	confirmation, err := s.publishChannel.PublishWithDeferredConfirmWithContext(
 		ctx,
 		exchange,   // exchange
 		routingKey, // routing key
-		false,      // mandatory
+		true,       // mandatory: return the message when no queue is bound to the routing key
 		false,      // immediate
 		msg,
 	)
+	if err != nil {
+		return err
+	}
+
+	confirmCtx, cancel := context.WithTimeout(ctx, r.confirmTimeout)
+	defer cancel()
+
+	acked, err := confirmation.WaitContext(confirmCtx)
+	// the broker returns an unroutable message before acking it
+	returned := s.takeReturn(msg.MessageId)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrNotConfirmed, routingKey, err)
+	}
+	if !acked {
+		return fmt.Errorf("%w: %s was nacked", ErrNotConfirmed, routingKey)
+	}
+	if returned != nil {
+		return fmt.Errorf("%w: %s (%s)", ErrUnroutable, routingKey, returned.ReplyText)
+	}
+
+	return nil
 }
 
-func (r *RabbitMQ) setupDeadLetterExchange() error {
+// takeReturn empties the returned messages, so the broker never blocks on them, and
+// gives back the one with the message id if it is among them
+func (s *session) takeReturn(messageID string) *amqp.Return {
+	var found *amqp.Return
+	for {
+		select {
+		case returned := <-s.returns:
+			if messageID != "" && returned.MessageId == messageID {
+				found = &returned
+				continue
+			}
+			log.Printf("message %s returned after its confirmation timed out: %s", returned.MessageId, returned.ReplyText)
+		default:
+			return found
+		}
+	}
+}
+
+func (r *RabbitMQ) setupDeadLetterExchange(ch *amqp.Channel) error {
 	// declare the dead letter exchange
-	if err := r.Channel.ExchangeDeclare(
+	if err := ch.ExchangeDeclare(
 		DeadLetterExchange, // name
 		"topic",            // type
 		true,               // durable
@@ -160,7 +248,7 @@ func (r *RabbitMQ) setupDeadLetterExchange() error {
 	}
 
 	// declare the dead letter queue
-	q, err := r.Channel.QueueDeclare(
+	q, err := ch.QueueDeclare(
 		DeadLetterQueue, // name
 		true,            // durable
 		false,           // delete when unused
@@ -172,7 +260,7 @@ func (r *RabbitMQ) setupDeadLetterExchange() error {
 		return fmt.Errorf("failed to declare dead letter queue: %w", err)
 	}
 
-	err = r.Channel.QueueBind(
+	err = ch.QueueBind(
 		q.Name,
 		"#", // wildcard routing key to catch all messages
 		DeadLetterExchange,
@@ -186,13 +274,13 @@ func (r *RabbitMQ) setupDeadLetterExchange() error {
 	return nil
 }
 
-func (r *RabbitMQ) setupExchangesAndQueues() error {
+func (r *RabbitMQ) setupExchangesAndQueues(ch *amqp.Channel) error {
 	// setup the DLQ
-	if err := r.setupDeadLetterExchange(); err != nil {
+	if err := r.setupDeadLetterExchange(ch); err != nil {
 		return err
 	}
 
-	if err := r.Channel.ExchangeDeclare(
+	if err := ch.ExchangeDeclare(
 		TripExchange, // name
 		"topic",      // type
 		true,         // durable
@@ -205,6 +293,7 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 	}
 
 	if err := r.declareAndBindQueue(
+		ch,
 		FindAvailableDriversQueue,
 		[]string{
 			contracts.TripEventCreated,
@@ -216,6 +305,7 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 	}
 
 	if err := r.declareAndBindQueue(
+		ch,
 		DriverCmdTripRequestQueue,
 		[]string{
 			contracts.DriverCmdTripRequest,
@@ -226,10 +316,23 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 	}
 
 	if err := r.declareAndBindQueue(
+		ch,
+		TripDriverOfferQueue,
+		[]string{
+			contracts.DriverCmdTripRequest,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		ch,
 		DriverTripResponseQueue,
 		[]string{
 			contracts.DriverCmdTripAccept,
 			contracts.DriverCmdTripDecline,
+			contracts.DriverCmdTripComplete,
 		},
 		TripExchange,
 	); err != nil {
@@ -237,6 +340,7 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 	}
 
 	if err := r.declareAndBindQueue(
+		ch,
 		NotifyDriversNoDriversFoundQueue,
 		[]string{
 			contracts.TripEventNoDriversFound,
@@ -247,6 +351,7 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 	}
 
 	if err := r.declareAndBindQueue(
+		ch,
 		NotifyDriverAssignQueue,
 		[]string{
 			contracts.TripEventDriverAssigned,
@@ -257,14 +362,21 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 	}
 
 	if err := r.declareAndBindQueue(
+		ch,
 		PaymentTripResponseQueue,
-		[]string{contracts.PaymentCmdCreateSession},
+		[]string{
+			contracts.PaymentCmdCreateSession,
+			contracts.PaymentCmdCreateTip,
+			// the fare of a trip with a scheduled charge is charged once it completes
+			contracts.TripEventCompleted,
+		},
 		TripExchange,
 	); err != nil {
 		return err
 	}
 
 	if err := r.declareAndBindQueue(
+		ch,
 		NotifyPaymentSessionCreatedQueue,
 		[]string{contracts.PaymentEventSessionCreated},
 		TripExchange,
@@ -273,8 +385,51 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 	}
 
 	if err := r.declareAndBindQueue(
-		NotifyPaymentSuccessQueue,
-		[]string{contracts.PaymentEventSuccess},
+		ch,
+		PaymentStatusQueue,
+		[]string{
+			contracts.PaymentEventSuccess,
+			contracts.PaymentEventFailed,
+			contracts.PaymentEventCancelled,
+			contracts.PaymentEventRefunded,
+			contracts.PaymentEventTipReceived,
+			contracts.PaymentEventTipFailed,
+			contracts.PaymentEventChargeScheduled,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		ch,
+		NotifyPaymentStatusQueue,
+		[]string{
+			contracts.PaymentEventSuccess,
+			contracts.PaymentEventFailed,
+			contracts.PaymentEventCancelled,
+			contracts.PaymentEventRefunded,
+			contracts.PaymentEventTipFailed,
+			contracts.PaymentEventChargeScheduled,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		ch,
+		NotifyTripCompletedQueue,
+		[]string{contracts.TripEventCompleted},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		ch,
+		NotifyDriverTipQueue,
+		[]string{contracts.PaymentEventTipReceived},
 		TripExchange,
 	); err != nil {
 		return err
@@ -283,13 +438,13 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 	return nil
 }
 
-func (r *RabbitMQ) declareAndBindQueue(queueName string, messageType []string, exchange string) error {
+func (r *RabbitMQ) declareAndBindQueue(ch *amqp.Channel, queueName string, messageType []string, exchange string) error {
 	// dead letter config
 	args := amqp.Table{
 		"x-dead-letter-exchange": DeadLetterExchange,
 	}
 
-	q, err := r.Channel.QueueDeclare(
+	q, err := ch.QueueDeclare(
 		queueName, // name
 		true,      // durable
 		false,     // delete when unused
@@ -302,7 +457,7 @@ func (r *RabbitMQ) declareAndBindQueue(queueName string, messageType []string, e
 	}
 
 	for _, msg := range messageType {
-		if err := r.Channel.QueueBind(
+		if err := ch.QueueBind(
 			q.Name,   // queue name
 			msg,      // routing key
 			exchange, // exchange
@@ -316,11 +471,18 @@ func (r *RabbitMQ) declareAndBindQueue(queueName string, messageType []string, e
 	return nil
 }
 
+// Close stops the supervisor before closing the connection, so it is not re-established
 func (r *RabbitMQ) Close() {
-	if r.conn != nil {
-		r.conn.Close()
-	}
-	if r.Channel != nil {
-		r.Channel.Close()
-	}
+	r.closeOnce.Do(func() {
+		close(r.done)
+		r.setState(StateClosed)
+
+		r.mutex.RLock()
+		s := r.session
+		r.mutex.RUnlock()
+
+		if s != nil {
+			s.close()
+		}
+	})
 }
@@ -0,0 +1,114 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// RetryCountHeader counts the retry tiers a message went through
+	RetryCountHeader = "x-retry-count"
+	// OriginalExchangeHeader and OriginalRoutingKeyHeader keep where a retried message was published,
+	// dead lettering it back to its queue replaces them with the default exchange and the queue name
+	OriginalExchangeHeader   = "x-original-exchange"
+	OriginalRoutingKeyHeader = "x-original-routing-key"
+)
+
+// RetryDelays are the tiers a failed message waits in before it is delivered again, once
+// through each of them. A message still failing after the last one goes to the DLQ.
+var RetryDelays = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	30 * time.Second,
+}
+
+// retryQueueName names the tier after its delay, a queue can't be declared again with another TTL
+func retryQueueName(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", queueName, delay)
+}
+
+// declareRetryQueues declares the tiers of the work queue. Messages are published to them through the
+// default exchange and dead lettered back to the work queue only, not to every queue of the routing key.
+func declareRetryQueues(ch *amqp.Channel, queueName string) error {
+	for _, delay := range RetryDelays {
+		args := amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		}
+
+		if _, err := ch.QueueDeclare(
+			retryQueueName(queueName, delay), // name
+			true,                             // durable
+			false,                            // delete when unused
+			false,                            // exclusive
+			false,                            // no-wait
+			args,                             // arguments
+		); err != nil {
+			return fmt.Errorf("failed to declare retry queue of %s: %w", queueName, err)
+		}
+	}
+
+	return nil
+}
+
+// scheduleRetry publishes the failed message to its next retry tier. It returns false when the
+// message went through all of them.
+func (r *RabbitMQ) scheduleRetry(ctx context.Context, queueName string, d amqp.Delivery) (bool, error) {
+	count := retryCount(d.Headers)
+	if count >= len(RetryDelays) {
+		return false, nil
+	}
+
+	headers := amqp.Table{}
+	for key, value := range d.Headers {
+		headers[key] = value
+	}
+	headers[RetryCountHeader] = int32(count + 1)
+	headers[OriginalExchangeHeader] = d.Exchange
+	headers[OriginalRoutingKeyHeader] = d.RoutingKey
+
+	msg := amqp.Publishing{
+		Headers:       headers,
+		ContentType:   d.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: d.CorrelationId,
+		MessageId:     d.MessageId,
+		Timestamp:     d.Timestamp,
+		Type:          d.Type,
+		AppId:         d.AppId,
+		Body:          d.Body,
+	}
+
+	if err := r.publish(ctx, "", retryQueueName(queueName, RetryDelays[count]), msg); err != nil {
+		return false, fmt.Errorf("failed to schedule retry %d of message %s: %w", count+1, d.MessageId, err)
+	}
+
+	return true, nil
+}
+
+func retryCount(headers amqp.Table) int {
+	switch count := headers[RetryCountHeader].(type) {
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	case int:
+		return count
+	default:
+		return 0
+	}
+}
+
+// restoreOrigin gives a retried message back the exchange and routing key it was published with
+func restoreOrigin(d *amqp.Delivery) {
+	if exchange, ok := d.Headers[OriginalExchangeHeader].(string); ok {
+		d.Exchange = exchange
+	}
+	if routingKey, ok := d.Headers[OriginalRoutingKeyHeader].(string); ok && routingKey != "" {
+		d.RoutingKey = routingKey
+	}
+}
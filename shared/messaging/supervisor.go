@@ -0,0 +1,202 @@
+package messaging
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ConnectionState is reported to health checks, only a connected RabbitMQ can publish and consume
+type ConnectionState string
+
+const (
+	StateConnected    ConnectionState = "connected"
+	StateReconnecting ConnectionState = "reconnecting"
+	StateClosed       ConnectionState = "closed"
+)
+
+func (r *RabbitMQ) State() ConnectionState {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.state
+}
+
+func (r *RabbitMQ) IsConnected() bool {
+	return r.State() == StateConnected
+}
+
+// OnStateChange calls fn with the current state, then every time the connection is lost or recovered
+func (r *RabbitMQ) OnStateChange(fn func(ConnectionState)) {
+	r.mutex.Lock()
+	r.listeners = append(r.listeners, fn)
+	state := r.state
+	r.mutex.Unlock()
+
+	fn(state)
+}
+
+func (r *RabbitMQ) setState(state ConnectionState) {
+	r.mutex.Lock()
+	changed := r.state != state
+	r.state = state
+	r.mutex.Unlock()
+
+	if changed {
+		r.notify(state)
+	}
+}
+
+func (r *RabbitMQ) notify(state ConnectionState) {
+	r.mutex.RLock()
+	listeners := append([]func(ConnectionState){}, r.listeners...)
+	r.mutex.RUnlock()
+
+	for _, fn := range listeners {
+		fn(state)
+	}
+}
+
+// dial opens the connection and its channels, and declares the exchanges and queues. The consumers
+// open their own channels.
+func (r *RabbitMQ) dial() (*session, error) {
+	conn, err := amqp.Dial(r.uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+	}
+
+	s := &session{conn: conn}
+
+	s.channel, err = conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open a channel: %v", err)
+	}
+
+	if err := r.setupExchangesAndQueues(s.channel); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set up exchanges and queues: %v", err)
+	}
+
+	// publishing has its own channel, confirms and returns would otherwise share it with the consumers
+	s.publishChannel, err = conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open the publishing channel: %v", err)
+	}
+
+	if err := s.publishChannel.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %v", err)
+	}
+	s.returns = s.publishChannel.NotifyReturn(make(chan amqp.Return, 16))
+
+	return s, nil
+}
+
+func (s *session) close() {
+	s.publishChannel.Close()
+	s.channel.Close()
+	s.conn.Close()
+}
+
+// supervise waits for the connection or its channel to close and re-establishes them, until Close is called
+func (r *RabbitMQ) supervise() {
+	for {
+		r.mutex.RLock()
+		s := r.session
+		r.mutex.RUnlock()
+
+		// they are all closed right away when the connection already went away
+		connClosed := s.conn.NotifyClose(make(chan *amqp.Error, 1))
+		chClosed := s.channel.NotifyClose(make(chan *amqp.Error, 1))
+		publishClosed := s.publishChannel.NotifyClose(make(chan *amqp.Error, 1))
+
+		var reason *amqp.Error
+		select {
+		case <-r.done:
+			return
+		case reason = <-connClosed:
+		case reason = <-chClosed:
+		case reason = <-publishClosed:
+		}
+
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+
+		log.Printf("RabbitMQ connection lost (%v), reconnecting", reason)
+		r.setState(StateReconnecting)
+		// a channel error leaves the connection open, start over from a new one all the same
+		s.conn.Close()
+
+		if !r.redial() {
+			return
+		}
+	}
+}
+
+// redial reconnects with backoff and restarts the registered consumers on the new channel.
+// It returns false when the RabbitMQ was closed in the meantime.
+func (r *RabbitMQ) redial() bool {
+	wait := r.reconnect.InitialWait
+
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-r.done:
+			return false
+		case <-time.After(wait):
+		}
+
+		s, err := r.dial()
+		if err == nil {
+			err = r.restartConsumers(s)
+		}
+		if err != nil {
+			log.Printf("RabbitMQ reconnection attempt %d failed: %v", attempt, err)
+			wait *= 2
+			if wait > r.reconnect.MaxWait {
+				wait = r.reconnect.MaxWait
+			}
+			continue
+		}
+
+		log.Printf("RabbitMQ reconnected after %d attempts", attempt)
+		r.notify(StateConnected)
+		return true
+	}
+}
+
+// restartConsumers switches to the new connection. It holds the lock until the state is connected
+// again, so a consumer registered meanwhile is either restarted here or started by consume.
+// Once Shutdown is draining the consumers stay cancelled, the handlers still running can publish.
+func (r *RabbitMQ) restartConsumers(s *session) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	select {
+	case <-r.done:
+		s.close()
+		return ErrNotConnected
+	default:
+	}
+
+	consumers := r.consumers
+	if r.draining {
+		consumers = nil
+	}
+
+	for _, c := range consumers {
+		if err := r.startConsumer(s, c); err != nil {
+			s.close()
+			return fmt.Errorf("failed to restart consumer %s: %w", c.tag, err)
+		}
+	}
+
+	r.session = s
+	r.state = StateConnected
+	return nil
+}
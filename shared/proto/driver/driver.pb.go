@@ -284,7 +284,7 @@ const file_driver_proto_rawDesc = "" +
 	"\tlongitude\x18\x02 \x01(\x01R\tlongitude2\xb7\x01\n" +
 	"\rDriverService\x12Q\n" +
 	"\x0eRegisterDriver\x12\x1d.driver.RegisterDriverRequest\x1a\x1e.driver.RegisterDriverResponse\"\x00\x12S\n" +
-	"\x10UnregisterDriver\x12\x1d.driver.RegisterDriverRequest\x1a\x1e.driver.RegisterDriverResponse\"\x00B\x1cZ\x1ashared/proto/driver;driverb\x06proto3"
+	"\x10UnregisterDriver\x12\x1d.driver.RegisterDriverRequest\x1a\x1e.driver.RegisterDriverResponse\"\x00B;Z9github.com/tenteedee/mini-uber/shared/proto/driver;driverb\x06proto3"
 
 var (
 	file_driver_proto_rawDescOnce sync.Once
@@ -0,0 +1,1521 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v3.21.12
+// source: payment.proto
+
+package payment
+
+import (
+	money "github.com/tenteedee/mini-uber/shared/proto/money"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetPaymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentID     string                 `protobuf:"bytes,1,opt,name=paymentID,proto3" json:"paymentID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPaymentRequest) Reset() {
+	*x = GetPaymentRequest{}
+	mi := &file_payment_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPaymentRequest) ProtoMessage() {}
+
+func (x *GetPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPaymentRequest.ProtoReflect.Descriptor instead.
+func (*GetPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetPaymentRequest) GetPaymentID() string {
+	if x != nil {
+		return x.PaymentID
+	}
+	return ""
+}
+
+type GetPaymentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payment       *Payment               `protobuf:"bytes,1,opt,name=payment,proto3" json:"payment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPaymentResponse) Reset() {
+	*x = GetPaymentResponse{}
+	mi := &file_payment_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPaymentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPaymentResponse) ProtoMessage() {}
+
+func (x *GetPaymentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPaymentResponse.ProtoReflect.Descriptor instead.
+func (*GetPaymentResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetPaymentResponse) GetPayment() *Payment {
+	if x != nil {
+		return x.Payment
+	}
+	return nil
+}
+
+type ListPaymentsForTripRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentsForTripRequest) Reset() {
+	*x = ListPaymentsForTripRequest{}
+	mi := &file_payment_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentsForTripRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentsForTripRequest) ProtoMessage() {}
+
+func (x *ListPaymentsForTripRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentsForTripRequest.ProtoReflect.Descriptor instead.
+func (*ListPaymentsForTripRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListPaymentsForTripRequest) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+type ListPaymentsForTripResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payments      []*Payment             `protobuf:"bytes,1,rep,name=payments,proto3" json:"payments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentsForTripResponse) Reset() {
+	*x = ListPaymentsForTripResponse{}
+	mi := &file_payment_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentsForTripResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentsForTripResponse) ProtoMessage() {}
+
+func (x *ListPaymentsForTripResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentsForTripResponse.ProtoReflect.Descriptor instead.
+func (*ListPaymentsForTripResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListPaymentsForTripResponse) GetPayments() []*Payment {
+	if x != nil {
+		return x.Payments
+	}
+	return nil
+}
+
+type RefundPaymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentID     string                 `protobuf:"bytes,1,opt,name=paymentID,proto3" json:"paymentID,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Amount        *money.Money           `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefundPaymentRequest) Reset() {
+	*x = RefundPaymentRequest{}
+	mi := &file_payment_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundPaymentRequest) ProtoMessage() {}
+
+func (x *RefundPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundPaymentRequest.ProtoReflect.Descriptor instead.
+func (*RefundPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RefundPaymentRequest) GetPaymentID() string {
+	if x != nil {
+		return x.PaymentID
+	}
+	return ""
+}
+
+func (x *RefundPaymentRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RefundPaymentRequest) GetAmount() *money.Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+type RefundPaymentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Refund        *Refund                `protobuf:"bytes,1,opt,name=refund,proto3" json:"refund,omitempty"`
+	Payment       *Payment               `protobuf:"bytes,2,opt,name=payment,proto3" json:"payment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefundPaymentResponse) Reset() {
+	*x = RefundPaymentResponse{}
+	mi := &file_payment_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundPaymentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundPaymentResponse) ProtoMessage() {}
+
+func (x *RefundPaymentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundPaymentResponse.ProtoReflect.Descriptor instead.
+func (*RefundPaymentResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RefundPaymentResponse) GetRefund() *Refund {
+	if x != nil {
+		return x.Refund
+	}
+	return nil
+}
+
+func (x *RefundPaymentResponse) GetPayment() *Payment {
+	if x != nil {
+		return x.Payment
+	}
+	return nil
+}
+
+type GetDriverEarningsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DriverID      string                 `protobuf:"bytes,1,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	GroupBy       string                 `protobuf:"bytes,4,opt,name=groupBy,proto3" json:"groupBy,omitempty"`
+	Currency      string                 `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDriverEarningsRequest) Reset() {
+	*x = GetDriverEarningsRequest{}
+	mi := &file_payment_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDriverEarningsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDriverEarningsRequest) ProtoMessage() {}
+
+func (x *GetDriverEarningsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDriverEarningsRequest.ProtoReflect.Descriptor instead.
+func (*GetDriverEarningsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetDriverEarningsRequest) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+func (x *GetDriverEarningsRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetDriverEarningsRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetDriverEarningsRequest) GetGroupBy() string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return ""
+}
+
+func (x *GetDriverEarningsRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+// EarningsTotals are all in the currency of the response
+type EarningsTotals struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Fares            *money.Money           `protobuf:"bytes,1,opt,name=fares,proto3" json:"fares,omitempty"`
+	Tips             *money.Money           `protobuf:"bytes,2,opt,name=tips,proto3" json:"tips,omitempty"`
+	CancellationFees *money.Money           `protobuf:"bytes,3,opt,name=cancellationFees,proto3" json:"cancellationFees,omitempty"`
+	Refunds          *money.Money           `protobuf:"bytes,4,opt,name=refunds,proto3" json:"refunds,omitempty"`
+	Commission       *money.Money           `protobuf:"bytes,5,opt,name=commission,proto3" json:"commission,omitempty"`
+	PaidOut          *money.Money           `protobuf:"bytes,6,opt,name=paidOut,proto3" json:"paidOut,omitempty"`
+	Net              *money.Money           `protobuf:"bytes,7,opt,name=net,proto3" json:"net,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *EarningsTotals) Reset() {
+	*x = EarningsTotals{}
+	mi := &file_payment_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EarningsTotals) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EarningsTotals) ProtoMessage() {}
+
+func (x *EarningsTotals) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EarningsTotals.ProtoReflect.Descriptor instead.
+func (*EarningsTotals) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *EarningsTotals) GetFares() *money.Money {
+	if x != nil {
+		return x.Fares
+	}
+	return nil
+}
+
+func (x *EarningsTotals) GetTips() *money.Money {
+	if x != nil {
+		return x.Tips
+	}
+	return nil
+}
+
+func (x *EarningsTotals) GetCancellationFees() *money.Money {
+	if x != nil {
+		return x.CancellationFees
+	}
+	return nil
+}
+
+func (x *EarningsTotals) GetRefunds() *money.Money {
+	if x != nil {
+		return x.Refunds
+	}
+	return nil
+}
+
+func (x *EarningsTotals) GetCommission() *money.Money {
+	if x != nil {
+		return x.Commission
+	}
+	return nil
+}
+
+func (x *EarningsTotals) GetPaidOut() *money.Money {
+	if x != nil {
+		return x.PaidOut
+	}
+	return nil
+}
+
+func (x *EarningsTotals) GetNet() *money.Money {
+	if x != nil {
+		return x.Net
+	}
+	return nil
+}
+
+type PeriodEarnings struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	Totals        *EarningsTotals        `protobuf:"bytes,2,opt,name=totals,proto3" json:"totals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeriodEarnings) Reset() {
+	*x = PeriodEarnings{}
+	mi := &file_payment_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeriodEarnings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeriodEarnings) ProtoMessage() {}
+
+func (x *PeriodEarnings) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeriodEarnings.ProtoReflect.Descriptor instead.
+func (*PeriodEarnings) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PeriodEarnings) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *PeriodEarnings) GetTotals() *EarningsTotals {
+	if x != nil {
+		return x.Totals
+	}
+	return nil
+}
+
+type TripEarnings struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	Totals        *EarningsTotals        `protobuf:"bytes,2,opt,name=totals,proto3" json:"totals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TripEarnings) Reset() {
+	*x = TripEarnings{}
+	mi := &file_payment_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TripEarnings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TripEarnings) ProtoMessage() {}
+
+func (x *TripEarnings) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TripEarnings.ProtoReflect.Descriptor instead.
+func (*TripEarnings) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TripEarnings) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *TripEarnings) GetTotals() *EarningsTotals {
+	if x != nil {
+		return x.Totals
+	}
+	return nil
+}
+
+type GetDriverEarningsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DriverID      string                 `protobuf:"bytes,1,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	Currency      string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	Totals        *EarningsTotals        `protobuf:"bytes,5,opt,name=totals,proto3" json:"totals,omitempty"`
+	Periods       []*PeriodEarnings      `protobuf:"bytes,6,rep,name=periods,proto3" json:"periods,omitempty"`
+	Trips         []*TripEarnings        `protobuf:"bytes,7,rep,name=trips,proto3" json:"trips,omitempty"`
+	Balance       *money.Money           `protobuf:"bytes,8,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDriverEarningsResponse) Reset() {
+	*x = GetDriverEarningsResponse{}
+	mi := &file_payment_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDriverEarningsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDriverEarningsResponse) ProtoMessage() {}
+
+func (x *GetDriverEarningsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDriverEarningsResponse.ProtoReflect.Descriptor instead.
+func (*GetDriverEarningsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetDriverEarningsResponse) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+func (x *GetDriverEarningsResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *GetDriverEarningsResponse) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetDriverEarningsResponse) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetDriverEarningsResponse) GetTotals() *EarningsTotals {
+	if x != nil {
+		return x.Totals
+	}
+	return nil
+}
+
+func (x *GetDriverEarningsResponse) GetPeriods() []*PeriodEarnings {
+	if x != nil {
+		return x.Periods
+	}
+	return nil
+}
+
+func (x *GetDriverEarningsResponse) GetTrips() []*TripEarnings {
+	if x != nil {
+		return x.Trips
+	}
+	return nil
+}
+
+func (x *GetDriverEarningsResponse) GetBalance() *money.Money {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+type CreateSetupSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserID        string                 `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSetupSessionRequest) Reset() {
+	*x = CreateSetupSessionRequest{}
+	mi := &file_payment_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSetupSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSetupSessionRequest) ProtoMessage() {}
+
+func (x *CreateSetupSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSetupSessionRequest.ProtoReflect.Descriptor instead.
+func (*CreateSetupSessionRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CreateSetupSessionRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+type CreateSetupSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionID     string                 `protobuf:"bytes,1,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+	SetupURL      string                 `protobuf:"bytes,2,opt,name=setupURL,proto3" json:"setupURL,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSetupSessionResponse) Reset() {
+	*x = CreateSetupSessionResponse{}
+	mi := &file_payment_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSetupSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSetupSessionResponse) ProtoMessage() {}
+
+func (x *CreateSetupSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSetupSessionResponse.ProtoReflect.Descriptor instead.
+func (*CreateSetupSessionResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CreateSetupSessionResponse) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+func (x *CreateSetupSessionResponse) GetSetupURL() string {
+	if x != nil {
+		return x.SetupURL
+	}
+	return ""
+}
+
+type ListPaymentMethodsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserID        string                 `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentMethodsRequest) Reset() {
+	*x = ListPaymentMethodsRequest{}
+	mi := &file_payment_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentMethodsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentMethodsRequest) ProtoMessage() {}
+
+func (x *ListPaymentMethodsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentMethodsRequest.ProtoReflect.Descriptor instead.
+func (*ListPaymentMethodsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListPaymentMethodsRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+type ListPaymentMethodsResponse struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	PaymentMethods         []*PaymentMethod       `protobuf:"bytes,1,rep,name=paymentMethods,proto3" json:"paymentMethods,omitempty"`
+	DefaultPaymentMethodID string                 `protobuf:"bytes,2,opt,name=defaultPaymentMethodID,proto3" json:"defaultPaymentMethodID,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *ListPaymentMethodsResponse) Reset() {
+	*x = ListPaymentMethodsResponse{}
+	mi := &file_payment_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentMethodsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentMethodsResponse) ProtoMessage() {}
+
+func (x *ListPaymentMethodsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentMethodsResponse.ProtoReflect.Descriptor instead.
+func (*ListPaymentMethodsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListPaymentMethodsResponse) GetPaymentMethods() []*PaymentMethod {
+	if x != nil {
+		return x.PaymentMethods
+	}
+	return nil
+}
+
+func (x *ListPaymentMethodsResponse) GetDefaultPaymentMethodID() string {
+	if x != nil {
+		return x.DefaultPaymentMethodID
+	}
+	return ""
+}
+
+type SetDefaultPaymentMethodRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserID          string                 `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	PaymentMethodID string                 `protobuf:"bytes,2,opt,name=paymentMethodID,proto3" json:"paymentMethodID,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SetDefaultPaymentMethodRequest) Reset() {
+	*x = SetDefaultPaymentMethodRequest{}
+	mi := &file_payment_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDefaultPaymentMethodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDefaultPaymentMethodRequest) ProtoMessage() {}
+
+func (x *SetDefaultPaymentMethodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDefaultPaymentMethodRequest.ProtoReflect.Descriptor instead.
+func (*SetDefaultPaymentMethodRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SetDefaultPaymentMethodRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+func (x *SetDefaultPaymentMethodRequest) GetPaymentMethodID() string {
+	if x != nil {
+		return x.PaymentMethodID
+	}
+	return ""
+}
+
+type RemovePaymentMethodRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserID          string                 `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	PaymentMethodID string                 `protobuf:"bytes,2,opt,name=paymentMethodID,proto3" json:"paymentMethodID,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RemovePaymentMethodRequest) Reset() {
+	*x = RemovePaymentMethodRequest{}
+	mi := &file_payment_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemovePaymentMethodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemovePaymentMethodRequest) ProtoMessage() {}
+
+func (x *RemovePaymentMethodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemovePaymentMethodRequest.ProtoReflect.Descriptor instead.
+func (*RemovePaymentMethodRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RemovePaymentMethodRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+func (x *RemovePaymentMethodRequest) GetPaymentMethodID() string {
+	if x != nil {
+		return x.PaymentMethodID
+	}
+	return ""
+}
+
+type PaymentMethod struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Brand         string                 `protobuf:"bytes,2,opt,name=brand,proto3" json:"brand,omitempty"`
+	Last4         string                 `protobuf:"bytes,3,opt,name=last4,proto3" json:"last4,omitempty"`
+	ExpMonth      int64                  `protobuf:"varint,4,opt,name=expMonth,proto3" json:"expMonth,omitempty"`
+	ExpYear       int64                  `protobuf:"varint,5,opt,name=expYear,proto3" json:"expYear,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PaymentMethod) Reset() {
+	*x = PaymentMethod{}
+	mi := &file_payment_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentMethod) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentMethod) ProtoMessage() {}
+
+func (x *PaymentMethod) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentMethod.ProtoReflect.Descriptor instead.
+func (*PaymentMethod) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PaymentMethod) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PaymentMethod) GetBrand() string {
+	if x != nil {
+		return x.Brand
+	}
+	return ""
+}
+
+func (x *PaymentMethod) GetLast4() string {
+	if x != nil {
+		return x.Last4
+	}
+	return ""
+}
+
+func (x *PaymentMethod) GetExpMonth() int64 {
+	if x != nil {
+		return x.ExpMonth
+	}
+	return 0
+}
+
+func (x *PaymentMethod) GetExpYear() int64 {
+	if x != nil {
+		return x.ExpYear
+	}
+	return 0
+}
+
+func (x *PaymentMethod) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type Payment struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TripID          string                 `protobuf:"bytes,2,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	UserID          string                 `protobuf:"bytes,3,opt,name=userID,proto3" json:"userID,omitempty"`
+	DriverID        string                 `protobuf:"bytes,4,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	Status          string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	StripeSessionID string                 `protobuf:"bytes,8,opt,name=stripeSessionID,proto3" json:"stripeSessionID,omitempty"`
+	FailureReason   string                 `protobuf:"bytes,9,opt,name=failureReason,proto3" json:"failureReason,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt       *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	Amount          *money.Money           `protobuf:"bytes,13,opt,name=amount,proto3" json:"amount,omitempty"`
+	RefundedAmount  *money.Money           `protobuf:"bytes,14,opt,name=refundedAmount,proto3" json:"refundedAmount,omitempty"`
+	Kind            string                 `protobuf:"bytes,15,opt,name=kind,proto3" json:"kind,omitempty"`
+	PackageSlug     string                 `protobuf:"bytes,16,opt,name=packageSlug,proto3" json:"packageSlug,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Payment) Reset() {
+	*x = Payment{}
+	mi := &file_payment_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Payment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Payment) ProtoMessage() {}
+
+func (x *Payment) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Payment.ProtoReflect.Descriptor instead.
+func (*Payment) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Payment) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Payment) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *Payment) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+func (x *Payment) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+func (x *Payment) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Payment) GetStripeSessionID() string {
+	if x != nil {
+		return x.StripeSessionID
+	}
+	return ""
+}
+
+func (x *Payment) GetFailureReason() string {
+	if x != nil {
+		return x.FailureReason
+	}
+	return ""
+}
+
+func (x *Payment) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Payment) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Payment) GetAmount() *money.Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *Payment) GetRefundedAmount() *money.Money {
+	if x != nil {
+		return x.RefundedAmount
+	}
+	return nil
+}
+
+func (x *Payment) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Payment) GetPackageSlug() string {
+	if x != nil {
+		return x.PackageSlug
+	}
+	return ""
+}
+
+type Refund struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	PaymentID         string                 `protobuf:"bytes,2,opt,name=paymentID,proto3" json:"paymentID,omitempty"`
+	TripID            string                 `protobuf:"bytes,3,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	Reason            string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	ProcessorRefundID string                 `protobuf:"bytes,7,opt,name=processorRefundID,proto3" json:"processorRefundID,omitempty"`
+	CreatedAt         *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	Amount            *money.Money           `protobuf:"bytes,9,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Refund) Reset() {
+	*x = Refund{}
+	mi := &file_payment_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Refund) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Refund) ProtoMessage() {}
+
+func (x *Refund) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Refund.ProtoReflect.Descriptor instead.
+func (*Refund) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Refund) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Refund) GetPaymentID() string {
+	if x != nil {
+		return x.PaymentID
+	}
+	return ""
+}
+
+func (x *Refund) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *Refund) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Refund) GetProcessorRefundID() string {
+	if x != nil {
+		return x.ProcessorRefundID
+	}
+	return ""
+}
+
+func (x *Refund) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Refund) GetAmount() *money.Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+var File_payment_proto protoreflect.FileDescriptor
+
+const file_payment_proto_rawDesc = "" +
+	"\n" +
+	"\rpayment.proto\x12\apayment\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\vmoney.proto\"1\n" +
+	"\x11GetPaymentRequest\x12\x1c\n" +
+	"\tpaymentID\x18\x01 \x01(\tR\tpaymentID\"@\n" +
+	"\x12GetPaymentResponse\x12*\n" +
+	"\apayment\x18\x01 \x01(\v2\x10.payment.PaymentR\apayment\"4\n" +
+	"\x1aListPaymentsForTripRequest\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\"K\n" +
+	"\x1bListPaymentsForTripResponse\x12,\n" +
+	"\bpayments\x18\x01 \x03(\v2\x10.payment.PaymentR\bpayments\"x\n" +
+	"\x14RefundPaymentRequest\x12\x1c\n" +
+	"\tpaymentID\x18\x01 \x01(\tR\tpaymentID\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12$\n" +
+	"\x06amount\x18\x04 \x01(\v2\f.money.MoneyR\x06amountJ\x04\b\x02\x10\x03\"l\n" +
+	"\x15RefundPaymentResponse\x12'\n" +
+	"\x06refund\x18\x01 \x01(\v2\x0f.payment.RefundR\x06refund\x12*\n" +
+	"\apayment\x18\x02 \x01(\v2\x10.payment.PaymentR\apayment\"\xc8\x01\n" +
+	"\x18GetDriverEarningsRequest\x12\x1a\n" +
+	"\bdriverID\x18\x01 \x01(\tR\bdriverID\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x18\n" +
+	"\agroupBy\x18\x04 \x01(\tR\agroupBy\x12\x1a\n" +
+	"\bcurrency\x18\x05 \x01(\tR\bcurrency\"\xae\x02\n" +
+	"\x0eEarningsTotals\x12\"\n" +
+	"\x05fares\x18\x01 \x01(\v2\f.money.MoneyR\x05fares\x12 \n" +
+	"\x04tips\x18\x02 \x01(\v2\f.money.MoneyR\x04tips\x128\n" +
+	"\x10cancellationFees\x18\x03 \x01(\v2\f.money.MoneyR\x10cancellationFees\x12&\n" +
+	"\arefunds\x18\x04 \x01(\v2\f.money.MoneyR\arefunds\x12,\n" +
+	"\n" +
+	"commission\x18\x05 \x01(\v2\f.money.MoneyR\n" +
+	"commission\x12&\n" +
+	"\apaidOut\x18\x06 \x01(\v2\f.money.MoneyR\apaidOut\x12\x1e\n" +
+	"\x03net\x18\a \x01(\v2\f.money.MoneyR\x03net\"s\n" +
+	"\x0ePeriodEarnings\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12/\n" +
+	"\x06totals\x18\x02 \x01(\v2\x17.payment.EarningsTotalsR\x06totals\"W\n" +
+	"\fTripEarnings\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12/\n" +
+	"\x06totals\x18\x02 \x01(\v2\x17.payment.EarningsTotalsR\x06totals\"\xe8\x02\n" +
+	"\x19GetDriverEarningsResponse\x12\x1a\n" +
+	"\bdriverID\x18\x01 \x01(\tR\bdriverID\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\x12.\n" +
+	"\x04from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12/\n" +
+	"\x06totals\x18\x05 \x01(\v2\x17.payment.EarningsTotalsR\x06totals\x121\n" +
+	"\aperiods\x18\x06 \x03(\v2\x17.payment.PeriodEarningsR\aperiods\x12+\n" +
+	"\x05trips\x18\a \x03(\v2\x15.payment.TripEarningsR\x05trips\x12&\n" +
+	"\abalance\x18\b \x01(\v2\f.money.MoneyR\abalance\"3\n" +
+	"\x19CreateSetupSessionRequest\x12\x16\n" +
+	"\x06userID\x18\x01 \x01(\tR\x06userID\"V\n" +
+	"\x1aCreateSetupSessionResponse\x12\x1c\n" +
+	"\tsessionID\x18\x01 \x01(\tR\tsessionID\x12\x1a\n" +
+	"\bsetupURL\x18\x02 \x01(\tR\bsetupURL\"3\n" +
+	"\x19ListPaymentMethodsRequest\x12\x16\n" +
+	"\x06userID\x18\x01 \x01(\tR\x06userID\"\x94\x01\n" +
+	"\x1aListPaymentMethodsResponse\x12>\n" +
+	"\x0epaymentMethods\x18\x01 \x03(\v2\x16.payment.PaymentMethodR\x0epaymentMethods\x126\n" +
+	"\x16defaultPaymentMethodID\x18\x02 \x01(\tR\x16defaultPaymentMethodID\"b\n" +
+	"\x1eSetDefaultPaymentMethodRequest\x12\x16\n" +
+	"\x06userID\x18\x01 \x01(\tR\x06userID\x12(\n" +
+	"\x0fpaymentMethodID\x18\x02 \x01(\tR\x0fpaymentMethodID\"^\n" +
+	"\x1aRemovePaymentMethodRequest\x12\x16\n" +
+	"\x06userID\x18\x01 \x01(\tR\x06userID\x12(\n" +
+	"\x0fpaymentMethodID\x18\x02 \x01(\tR\x0fpaymentMethodID\"\xbb\x01\n" +
+	"\rPaymentMethod\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05brand\x18\x02 \x01(\tR\x05brand\x12\x14\n" +
+	"\x05last4\x18\x03 \x01(\tR\x05last4\x12\x1a\n" +
+	"\bexpMonth\x18\x04 \x01(\x03R\bexpMonth\x12\x18\n" +
+	"\aexpYear\x18\x05 \x01(\x03R\aexpYear\x128\n" +
+	"\tcreatedAt\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\xef\x03\n" +
+	"\aPayment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06tripID\x18\x02 \x01(\tR\x06tripID\x12\x16\n" +
+	"\x06userID\x18\x03 \x01(\tR\x06userID\x12\x1a\n" +
+	"\bdriverID\x18\x04 \x01(\tR\bdriverID\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12(\n" +
+	"\x0fstripeSessionID\x18\b \x01(\tR\x0fstripeSessionID\x12$\n" +
+	"\rfailureReason\x18\t \x01(\tR\rfailureReason\x128\n" +
+	"\tcreatedAt\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x128\n" +
+	"\tupdatedAt\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12$\n" +
+	"\x06amount\x18\r \x01(\v2\f.money.MoneyR\x06amount\x124\n" +
+	"\x0erefundedAmount\x18\x0e \x01(\v2\f.money.MoneyR\x0erefundedAmount\x12\x12\n" +
+	"\x04kind\x18\x0f \x01(\tR\x04kind\x12 \n" +
+	"\vpackageSlug\x18\x10 \x01(\tR\vpackageSlugJ\x04\b\x05\x10\x06J\x04\b\x06\x10\aJ\x04\b\f\x10\rR\bcurrency\"\x8a\x02\n" +
+	"\x06Refund\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\tpaymentID\x18\x02 \x01(\tR\tpaymentID\x12\x16\n" +
+	"\x06tripID\x18\x03 \x01(\tR\x06tripID\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\x12,\n" +
+	"\x11processorRefundID\x18\a \x01(\tR\x11processorRefundID\x128\n" +
+	"\tcreatedAt\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12$\n" +
+	"\x06amount\x18\t \x01(\v2\f.money.MoneyR\x06amountJ\x04\b\x04\x10\x05J\x04\b\x05\x10\x06R\bcurrency2\xfd\x05\n" +
+	"\x0ePaymentService\x12G\n" +
+	"\n" +
+	"GetPayment\x12\x1a.payment.GetPaymentRequest\x1a\x1b.payment.GetPaymentResponse\"\x00\x12b\n" +
+	"\x13ListPaymentsForTrip\x12#.payment.ListPaymentsForTripRequest\x1a$.payment.ListPaymentsForTripResponse\"\x00\x12P\n" +
+	"\rRefundPayment\x12\x1d.payment.RefundPaymentRequest\x1a\x1e.payment.RefundPaymentResponse\"\x00\x12\\\n" +
+	"\x11GetDriverEarnings\x12!.payment.GetDriverEarningsRequest\x1a\".payment.GetDriverEarningsResponse\"\x00\x12_\n" +
+	"\x12CreateSetupSession\x12\".payment.CreateSetupSessionRequest\x1a#.payment.CreateSetupSessionResponse\"\x00\x12_\n" +
+	"\x12ListPaymentMethods\x12\".payment.ListPaymentMethodsRequest\x1a#.payment.ListPaymentMethodsResponse\"\x00\x12i\n" +
+	"\x17SetDefaultPaymentMethod\x12'.payment.SetDefaultPaymentMethodRequest\x1a#.payment.ListPaymentMethodsResponse\"\x00\x12a\n" +
+	"\x13RemovePaymentMethod\x12#.payment.RemovePaymentMethodRequest\x1a#.payment.ListPaymentMethodsResponse\"\x00B=Z;github.com/tenteedee/mini-uber/shared/proto/payment;paymentb\x06proto3"
+
+var (
+	file_payment_proto_rawDescOnce sync.Once
+	file_payment_proto_rawDescData []byte
+)
+
+func file_payment_proto_rawDescGZIP() []byte {
+	file_payment_proto_rawDescOnce.Do(func() {
+		file_payment_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_payment_proto_rawDesc), len(file_payment_proto_rawDesc)))
+	})
+	return file_payment_proto_rawDescData
+}
+
+var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_payment_proto_goTypes = []any{
+	(*GetPaymentRequest)(nil),              // 0: payment.GetPaymentRequest
+	(*GetPaymentResponse)(nil),             // 1: payment.GetPaymentResponse
+	(*ListPaymentsForTripRequest)(nil),     // 2: payment.ListPaymentsForTripRequest
+	(*ListPaymentsForTripResponse)(nil),    // 3: payment.ListPaymentsForTripResponse
+	(*RefundPaymentRequest)(nil),           // 4: payment.RefundPaymentRequest
+	(*RefundPaymentResponse)(nil),          // 5: payment.RefundPaymentResponse
+	(*GetDriverEarningsRequest)(nil),       // 6: payment.GetDriverEarningsRequest
+	(*EarningsTotals)(nil),                 // 7: payment.EarningsTotals
+	(*PeriodEarnings)(nil),                 // 8: payment.PeriodEarnings
+	(*TripEarnings)(nil),                   // 9: payment.TripEarnings
+	(*GetDriverEarningsResponse)(nil),      // 10: payment.GetDriverEarningsResponse
+	(*CreateSetupSessionRequest)(nil),      // 11: payment.CreateSetupSessionRequest
+	(*CreateSetupSessionResponse)(nil),     // 12: payment.CreateSetupSessionResponse
+	(*ListPaymentMethodsRequest)(nil),      // 13: payment.ListPaymentMethodsRequest
+	(*ListPaymentMethodsResponse)(nil),     // 14: payment.ListPaymentMethodsResponse
+	(*SetDefaultPaymentMethodRequest)(nil), // 15: payment.SetDefaultPaymentMethodRequest
+	(*RemovePaymentMethodRequest)(nil),     // 16: payment.RemovePaymentMethodRequest
+	(*PaymentMethod)(nil),                  // 17: payment.PaymentMethod
+	(*Payment)(nil),                        // 18: payment.Payment
+	(*Refund)(nil),                         // 19: payment.Refund
+	(*money.Money)(nil),                    // 20: money.Money
+	(*timestamppb.Timestamp)(nil),          // 21: google.protobuf.Timestamp
+}
+var file_payment_proto_depIdxs = []int32{
+	18, // 0: payment.GetPaymentResponse.payment:type_name -> payment.Payment
+	18, // 1: payment.ListPaymentsForTripResponse.payments:type_name -> payment.Payment
+	20, // 2: payment.RefundPaymentRequest.amount:type_name -> money.Money
+	19, // 3: payment.RefundPaymentResponse.refund:type_name -> payment.Refund
+	18, // 4: payment.RefundPaymentResponse.payment:type_name -> payment.Payment
+	21, // 5: payment.GetDriverEarningsRequest.from:type_name -> google.protobuf.Timestamp
+	21, // 6: payment.GetDriverEarningsRequest.to:type_name -> google.protobuf.Timestamp
+	20, // 7: payment.EarningsTotals.fares:type_name -> money.Money
+	20, // 8: payment.EarningsTotals.tips:type_name -> money.Money
+	20, // 9: payment.EarningsTotals.cancellationFees:type_name -> money.Money
+	20, // 10: payment.EarningsTotals.refunds:type_name -> money.Money
+	20, // 11: payment.EarningsTotals.commission:type_name -> money.Money
+	20, // 12: payment.EarningsTotals.paidOut:type_name -> money.Money
+	20, // 13: payment.EarningsTotals.net:type_name -> money.Money
+	21, // 14: payment.PeriodEarnings.start:type_name -> google.protobuf.Timestamp
+	7,  // 15: payment.PeriodEarnings.totals:type_name -> payment.EarningsTotals
+	7,  // 16: payment.TripEarnings.totals:type_name -> payment.EarningsTotals
+	21, // 17: payment.GetDriverEarningsResponse.from:type_name -> google.protobuf.Timestamp
+	21, // 18: payment.GetDriverEarningsResponse.to:type_name -> google.protobuf.Timestamp
+	7,  // 19: payment.GetDriverEarningsResponse.totals:type_name -> payment.EarningsTotals
+	8,  // 20: payment.GetDriverEarningsResponse.periods:type_name -> payment.PeriodEarnings
+	9,  // 21: payment.GetDriverEarningsResponse.trips:type_name -> payment.TripEarnings
+	20, // 22: payment.GetDriverEarningsResponse.balance:type_name -> money.Money
+	17, // 23: payment.ListPaymentMethodsResponse.paymentMethods:type_name -> payment.PaymentMethod
+	21, // 24: payment.PaymentMethod.createdAt:type_name -> google.protobuf.Timestamp
+	21, // 25: payment.Payment.createdAt:type_name -> google.protobuf.Timestamp
+	21, // 26: payment.Payment.updatedAt:type_name -> google.protobuf.Timestamp
+	20, // 27: payment.Payment.amount:type_name -> money.Money
+	20, // 28: payment.Payment.refundedAmount:type_name -> money.Money
+	21, // 29: payment.Refund.createdAt:type_name -> google.protobuf.Timestamp
+	20, // 30: payment.Refund.amount:type_name -> money.Money
+	0,  // 31: payment.PaymentService.GetPayment:input_type -> payment.GetPaymentRequest
+	2,  // 32: payment.PaymentService.ListPaymentsForTrip:input_type -> payment.ListPaymentsForTripRequest
+	4,  // 33: payment.PaymentService.RefundPayment:input_type -> payment.RefundPaymentRequest
+	6,  // 34: payment.PaymentService.GetDriverEarnings:input_type -> payment.GetDriverEarningsRequest
+	11, // 35: payment.PaymentService.CreateSetupSession:input_type -> payment.CreateSetupSessionRequest
+	13, // 36: payment.PaymentService.ListPaymentMethods:input_type -> payment.ListPaymentMethodsRequest
+	15, // 37: payment.PaymentService.SetDefaultPaymentMethod:input_type -> payment.SetDefaultPaymentMethodRequest
+	16, // 38: payment.PaymentService.RemovePaymentMethod:input_type -> payment.RemovePaymentMethodRequest
+	1,  // 39: payment.PaymentService.GetPayment:output_type -> payment.GetPaymentResponse
+	3,  // 40: payment.PaymentService.ListPaymentsForTrip:output_type -> payment.ListPaymentsForTripResponse
+	5,  // 41: payment.PaymentService.RefundPayment:output_type -> payment.RefundPaymentResponse
+	10, // 42: payment.PaymentService.GetDriverEarnings:output_type -> payment.GetDriverEarningsResponse
+	12, // 43: payment.PaymentService.CreateSetupSession:output_type -> payment.CreateSetupSessionResponse
+	14, // 44: payment.PaymentService.ListPaymentMethods:output_type -> payment.ListPaymentMethodsResponse
+	14, // 45: payment.PaymentService.SetDefaultPaymentMethod:output_type -> payment.ListPaymentMethodsResponse
+	14, // 46: payment.PaymentService.RemovePaymentMethod:output_type -> payment.ListPaymentMethodsResponse
+	39, // [39:47] is the sub-list for method output_type
+	31, // [31:39] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
+}
+
+func init() { file_payment_proto_init() }
+func file_payment_proto_init() {
+	if File_payment_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_payment_proto_rawDesc), len(file_payment_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_payment_proto_goTypes,
+		DependencyIndexes: file_payment_proto_depIdxs,
+		MessageInfos:      file_payment_proto_msgTypes,
+	}.Build()
+	File_payment_proto = out.File
+	file_payment_proto_goTypes = nil
+	file_payment_proto_depIdxs = nil
+}
@@ -2,7 +2,6 @@ package tracing
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/tenteedee/mini-uber/shared/contracts"
 
@@ -49,13 +48,7 @@ func TracedPublisher(ctx context.Context, exchange, routingKey string, msg amqp.
 	)
 	defer span.End()
 
-	// extract and add message details to span (map[string]any)
-	var msgBody contracts.AmqpMessage
-	if err := json.Unmarshal(msg.Body, &msgBody); err == nil {
-		if msgBody.OwnerID != "" {
-			span.SetAttributes(attribute.String("messaging.owner_id", msgBody.OwnerID))
-		}
-	}
+	span.SetAttributes(messageAttributes(msg.MessageId, msg.ContentType, msg.Headers, msg.Body)...)
 
 	// Inject trace context into message headers
 	if msg.Headers == nil {
@@ -89,13 +82,7 @@ func TracedConsumer(delivery amqp.Delivery, handler func(context.Context, amqp.D
 	)
 	defer span.End()
 
-	// extract and add message details to span (map[string]any)
-	var msgBody contracts.AmqpMessage
-	if err := json.Unmarshal(delivery.Body, &msgBody); err == nil {
-		if msgBody.OwnerID != "" {
-			span.SetAttributes(attribute.String("messaging.owner_id", msgBody.OwnerID))
-		}
-	}
+	span.SetAttributes(messageAttributes(delivery.MessageId, delivery.ContentType, delivery.Headers, delivery.Body)...)
 
 	if err := handler(ctx, delivery); err != nil {
 		span.SetStatus(codes.Error, err.Error())
@@ -104,3 +91,42 @@ func TracedConsumer(delivery amqp.Delivery, handler func(context.Context, amqp.D
 
 	return nil
 }
+
+// messageAttributes describes the message with the metadata of its envelope, in its body or its headers.
+// A legacy one only has its owner.
+func messageAttributes(messageID, contentType string, headers amqp.Table, body []byte) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{}
+	if messageID != "" {
+		attributes = append(attributes, attribute.String("messaging.message.id", messageID))
+	}
+
+	envelope, err := contracts.DecodeMessage(contentType, headers, body)
+	if err != nil {
+		return attributes
+	}
+
+	if envelope.OwnerID != "" {
+		attributes = append(attributes, attribute.String("messaging.owner_id", envelope.OwnerID))
+	}
+	if envelope.IsLegacy() {
+		return attributes
+	}
+
+	attributes = append(attributes,
+		attribute.String("cloudevents.event_id", envelope.ID),
+		attribute.String("cloudevents.event_source", envelope.Source),
+		attribute.String("cloudevents.event_type", envelope.Type),
+		attribute.String("cloudevents.event_spec_version", envelope.SpecVersion),
+	)
+	if envelope.DataSchema != "" {
+		attributes = append(attributes, attribute.String("cloudevents.event_data_schema", envelope.DataSchema))
+	}
+	if envelope.CorrelationID != "" {
+		attributes = append(attributes, attribute.String("messaging.message.conversation_id", envelope.CorrelationID))
+	}
+	if envelope.CausationID != "" {
+		attributes = append(attributes, attribute.String("messaging.message.causation_id", envelope.CausationID))
+	}
+
+	return attributes
+}
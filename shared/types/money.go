@@ -0,0 +1,105 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	pb "github.com/tenteedee/mini-uber/shared/proto/money"
+)
+
+// DefaultCurrency is charged when a fare doesn't say otherwise
+const DefaultCurrency = "USD"
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not a hundredth
+// of the major unit. VND and JPY have no minor unit at all, so 1 is one whole dong or yen.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "JOD": 3, "KWD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent returns the number of decimals of the currency's major unit, e.g. 2 for USD and 0 for VND.
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Money is an amount in the minor unit of its currency (cents for USD, whole dong for VND),
+// so it never drifts by fractions of a unit. Currency is an ISO 4217 code, always upper case.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{
+		Amount:   amount,
+		Currency: strings.ToUpper(currency),
+	}
+}
+
+// RoundMoney turns a fractional amount of minor units, as computed by pricing, into Money.
+// This is the only place where rounding happens: to the nearest minor unit, halves away from zero.
+func RoundMoney(minorUnits float64, currency string) Money {
+	return NewMoney(int64(math.Round(minorUnits)), currency)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return NewMoney(m.Amount+other.Amount, m.Currency), nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return NewMoney(m.Amount-other.Amount, m.Currency), nil
+}
+
+// String formats the amount in major units, e.g. "12.34 USD" or "25000 VND"
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	exponent := CurrencyExponent(m.Currency)
+	if exponent == 0 {
+		return fmt.Sprintf("%s%d %s", sign, amount, m.Currency)
+	}
+
+	scale := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/scale, exponent, amount%scale, m.Currency)
+}
+
+func (m Money) ToProto() *pb.Money {
+	return &pb.Money{
+		Amount:   m.Amount,
+		Currency: m.Currency,
+	}
+}
+
+func MoneyFromProto(m *pb.Money) Money {
+	if m == nil {
+		return Money{}
+	}
+	return NewMoney(m.GetAmount(), m.GetCurrency())
+}
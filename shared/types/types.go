@@ -1,5 +1,7 @@
 package types
 
+import "math"
+
 type Route struct {
 	Distance float64     `json:"distance"`
 	Duration float64     `json:"duration"`
@@ -14,3 +16,28 @@ type Coordinate struct {
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
 }
+
+// DistanceTo returns the great-circle distance in meters between two coordinates.
+func (c Coordinate) DistanceTo(other Coordinate) float64 {
+	const earthRadius = 6371000 // meters
+
+	lat1 := c.Latitude * math.Pi / 180
+	lat2 := other.Latitude * math.Pi / 180
+	dLat := (other.Latitude - c.Latitude) * math.Pi / 180
+	dLon := (other.Longitude - c.Longitude) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// Car packages a rider can book and a driver can register with.
+const (
+	PackageSedan  = "sedan"
+	PackageSUV    = "suv"
+	PackageVan    = "van"
+	PackageLuxury = "luxury"
+)
+
+// PackageSlugs lists every valid package slug.
+var PackageSlugs = []string{PackageSedan, PackageSUV, PackageVan, PackageLuxury}
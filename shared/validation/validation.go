@@ -0,0 +1,104 @@
+/*
+Package validation collects field level errors for incoming requests.
+The same checks are used by the api-gateway and by the services behind it.
+*/
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tenteedee/mini-uber/shared/types"
+)
+
+// FieldError describes why a single request field is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validator accumulates field errors, so all of them can be reported at once.
+type Validator struct {
+	errors []FieldError
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+func (v *Validator) AddError(field, message string) {
+	v.errors = append(v.errors, FieldError{Field: field, Message: message})
+}
+
+func (v *Validator) Valid() bool {
+	return len(v.errors) == 0
+}
+
+func (v *Validator) Errors() []FieldError {
+	return v.errors
+}
+
+// Required checks that the value is not blank.
+func (v *Validator) Required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.AddError(field, "is required")
+	}
+}
+
+// Coordinate checks that the coordinate is set and within the valid latitude/longitude ranges.
+// 0,0 is what a missing coordinate decodes to, so it is rejected as well.
+func (v *Validator) Coordinate(field string, c *types.Coordinate) bool {
+	if c == nil {
+		v.AddError(field, "is required")
+		return false
+	}
+
+	valid := true
+	if c.Latitude < -90 || c.Latitude > 90 {
+		v.AddError(field+".latitude", "must be between -90 and 90")
+		valid = false
+	}
+	if c.Longitude < -180 || c.Longitude > 180 {
+		v.AddError(field+".longitude", "must be between -180 and 180")
+		valid = false
+	}
+	if valid && c.Latitude == 0 && c.Longitude == 0 {
+		v.AddError(field, "is required")
+		valid = false
+	}
+
+	return valid
+}
+
+// Trip checks the pickup and destination of a trip, including that the straight line
+// distance between them doesn't exceed maxDistanceMeters (0 disables the check).
+func (v *Validator) Trip(pickupField string, pickup *types.Coordinate, destinationField string, destination *types.Coordinate, maxDistanceMeters float64) {
+	pickupValid := v.Coordinate(pickupField, pickup)
+	destinationValid := v.Coordinate(destinationField, destination)
+	if !pickupValid || !destinationValid {
+		return
+	}
+
+	distance := pickup.DistanceTo(*destination)
+	if distance < 1 {
+		v.AddError(destinationField, "must be different from the pickup")
+		return
+	}
+
+	if maxDistanceMeters > 0 && distance > maxDistanceMeters {
+		v.AddError(destinationField, fmt.Sprintf("must be within %.0f km of the pickup", maxDistanceMeters/1000))
+	}
+}
+
+// PackageSlug checks that the slug is one of the car packages on offer.
+func (v *Validator) PackageSlug(field, slug string) {
+	if slug == "" {
+		v.AddError(field, "is required")
+		return
+	}
+
+	if !slices.Contains(types.PackageSlugs, slug) {
+		v.AddError(field, fmt.Sprintf("must be one of %s", strings.Join(types.PackageSlugs, ", ")))
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/tenteedee/mini-uber/shared/contracts"
+	"github.com/tenteedee/mini-uber/shared/messaging"
+)
+
+// dlqMessage is a dead lettered message with its failure headers decoded, as listed and exported
+type dlqMessage struct {
+	MessageID     string          `json:"messageId"`
+	RoutingKey    string          `json:"routingKey"`
+	Exchange      string          `json:"exchange"`
+	Queue         string          `json:"queue,omitempty"`
+	Reason        string          `json:"reason,omitempty"`
+	Service       string          `json:"service,omitempty"`
+	RetryCount    int64           `json:"retryCount"`
+	PublishedAt   *time.Time      `json:"publishedAt,omitempty"`
+	FailedAt      *time.Time      `json:"failedAt,omitempty"`
+	TraceID       string          `json:"traceId,omitempty"`
+	OwnerID       string          `json:"ownerId,omitempty"`
+	Source        string          `json:"source,omitempty"` // the envelope metadata, legacy messages have none
+	CorrelationID string          `json:"correlationId,omitempty"`
+	CausationID   string          `json:"causationId,omitempty"`
+	DataSchema    string          `json:"dataSchema,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+	DataBase64    []byte          `json:"dataBase64,omitempty"` // data that isn't JSON, e.g. protobuf
+	Body          string          `json:"body,omitempty"`       // when the body is in neither envelope
+	Headers       map[string]any  `json:"headers"`
+	deliveryTag   uint64
+	contentType   string
+	originalBody  []byte
+	originalTable amqp.Table
+}
+
+func newDLQMessage(d amqp.Delivery) *dlqMessage {
+	m := &dlqMessage{
+		MessageID:     d.MessageId,
+		RoutingKey:    headerString(d.Headers, messaging.OriginalRoutingKeyHeader, d.RoutingKey),
+		Exchange:      headerString(d.Headers, messaging.OriginalExchangeHeader, d.Exchange),
+		Queue:         headerString(d.Headers, messaging.OriginalQueueHeader, ""),
+		Reason:        headerString(d.Headers, messaging.DeathReasonHeader, ""),
+		Service:       headerString(d.Headers, messaging.FailedServiceHeader, ""),
+		TraceID:       headerString(d.Headers, messaging.TraceIDHeader, ""),
+		Headers:       make(map[string]any, len(d.Headers)),
+		deliveryTag:   d.DeliveryTag,
+		contentType:   d.ContentType,
+		originalBody:  d.Body,
+		originalTable: d.Headers,
+	}
+
+	switch count := d.Headers[messaging.RetryCountHeader].(type) {
+	case int32:
+		m.RetryCount = int64(count)
+	case int64:
+		m.RetryCount = count
+	}
+
+	if !d.Timestamp.IsZero() {
+		publishedAt := d.Timestamp
+		m.PublishedAt = &publishedAt
+	}
+	if failedAt, ok := d.Headers[messaging.FailedAtHeader].(time.Time); ok {
+		m.FailedAt = &failedAt
+	}
+
+	// messages rejected before failures were recorded only have the broker's x-death header
+	if m.Queue == "" {
+		if deaths, ok := d.Headers["x-death"].([]any); ok && len(deaths) > 0 {
+			if death, ok := deaths[0].(amqp.Table); ok {
+				m.Queue, _ = death["queue"].(string)
+				m.Exchange, _ = death["exchange"].(string)
+				if m.FailedAt == nil {
+					if failedAt, ok := death["time"].(time.Time); ok {
+						m.FailedAt = &failedAt
+					}
+				}
+			}
+		}
+	}
+
+	for key, value := range d.Headers {
+		m.Headers[key] = value
+	}
+
+	if envelope, err := contracts.DecodeMessage(d.ContentType, d.Headers, d.Body); err == nil {
+		m.OwnerID = envelope.OwnerID
+		m.Data = envelope.Data
+		m.DataBase64 = envelope.DataBase64
+		m.Source = envelope.Source
+		m.CorrelationID = envelope.CorrelationID
+		m.CausationID = envelope.CausationID
+		m.DataSchema = envelope.DataSchema
+	} else {
+		m.Body = string(d.Body)
+	}
+
+	return m
+}
+
+// failedAt falls back to when the message was published for the time filters
+func (m *dlqMessage) failedAt() time.Time {
+	if m.FailedAt != nil {
+		return *m.FailedAt
+	}
+	if m.PublishedAt != nil {
+		return *m.PublishedAt
+	}
+	return time.Time{}
+}
+
+// replayHeaders drops the failure headers, the replayed message starts over its retries
+func (m *dlqMessage) replayHeaders() amqp.Table {
+	headers := amqp.Table{}
+	for key, value := range m.originalTable {
+		if key == "x-death" || strings.HasPrefix(key, "x-first-death") || strings.HasPrefix(key, "x-last-death") {
+			continue
+		}
+		switch key {
+		case messaging.DeathReasonHeader,
+			messaging.OriginalExchangeHeader,
+			messaging.OriginalRoutingKeyHeader,
+			messaging.OriginalQueueHeader,
+			messaging.RetryCountHeader,
+			messaging.FailedServiceHeader,
+			messaging.FailedAtHeader,
+			messaging.TraceIDHeader,
+			messaging.SpanIDHeader:
+			continue
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
+type filter struct {
+	routingKey string
+	ownerID    string
+	since      time.Time
+	until      time.Time
+	ids        map[string]bool
+}
+
+func (f *filter) match(m *dlqMessage) bool {
+	if f.routingKey != "" && m.RoutingKey != f.routingKey {
+		return false
+	}
+	if f.ownerID != "" && m.OwnerID != f.ownerID {
+		return false
+	}
+	if len(f.ids) > 0 && !f.ids[m.MessageID] {
+		return false
+	}
+
+	failedAt := m.failedAt()
+	if !f.since.IsZero() && failedAt.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !failedAt.Before(f.until) {
+		return false
+	}
+	return true
+}
+
+func (m *dlqMessage) summary() string {
+	failedAt := "unknown time"
+	if t := m.failedAt(); !t.IsZero() {
+		failedAt = t.Format(time.RFC3339)
+	}
+
+	return fmt.Sprintf("%s %s (queue %s, owner %s, failed at %s by %s after %d retries): %s",
+		m.MessageID, m.RoutingKey, m.Queue, m.OwnerID, failedAt, m.Service, m.RetryCount, m.Reason)
+}
+
+func headerString(headers amqp.Table, key, fallback string) string {
+	if value, ok := headers[key].(string); ok && value != "" {
+		return value
+	}
+	return fallback
+}
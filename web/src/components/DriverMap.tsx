@@ -58,6 +58,7 @@ export const DriverMap = ({ packageSlug }: { packageSlug: CarPackageSlug }) => {
     driver,
     tripStatus,
     requestedTrip,
+    tip,
     sendMessage,
     setTripStatus,
     resetTripStatus,
@@ -112,6 +113,22 @@ export const DriverMap = ({ packageSlug }: { packageSlug: CarPackageSlug }) => {
     resetTripStatus();
   };
 
+  const handleCompleteTrip = () => {
+    if (!requestedTrip || !requestedTrip.id) {
+      alert("No trip ID found");
+      return;
+    }
+
+    sendMessage({
+      type: TripEvents.DriverTripComplete,
+      data: {
+        tripId: requestedTrip.id,
+      },
+    });
+
+    setTripStatus(TripEvents.DriverTripComplete);
+  };
+
   const parsedRoute = useMemo(
     () =>
       requestedTrip?.route?.geometry[0]?.coordinates.map(
@@ -198,6 +215,8 @@ export const DriverMap = ({ packageSlug }: { packageSlug: CarPackageSlug }) => {
             status={tripStatus}
             onAcceptTrip={handleAcceptTrip}
             onDeclineTrip={handleDeclineTrip}
+            onCompleteTrip={handleCompleteTrip}
+            tip={tip}
           />
         </div>
       </div>
@@ -1,13 +1,16 @@
 import { Trip } from "../types";
 import { TripOverviewCard } from "./TripOverviewCard";
 import { Button } from "./ui/button";
-import { TripEvents } from "../contracts";
+import { PaymentTipData, TripEvents } from "../contracts";
+import { formatMoney } from "../utils/money";
 
 interface DriverTripOverviewProps {
   trip?: Trip | null;
   status?: TripEvents | null;
   onAcceptTrip?: () => void;
   onDeclineTrip?: () => void;
+  onCompleteTrip?: () => void;
+  tip?: PaymentTipData | null;
 }
 
 export const DriverTripOverview = ({
@@ -15,6 +18,8 @@ export const DriverTripOverview = ({
   status,
   onAcceptTrip,
   onDeclineTrip,
+  onCompleteTrip,
+  tip,
 }: DriverTripOverviewProps) => {
   if (!trip) {
     return (
@@ -56,10 +61,27 @@ export const DriverTripOverview = ({
               Rider ID: {trip.userId}
             </p>
           </div>
+          <Button onClick={onCompleteTrip}>Complete trip</Button>
         </div>
       </TripOverviewCard>
     );
   }
 
+  if (
+    status === TripEvents.DriverTripComplete ||
+    status === TripEvents.PaymentTipReceived
+  ) {
+    return (
+      <TripOverviewCard
+        title="Trip completed!"
+        description={
+          tip
+            ? `The rider tipped you ${formatMoney(tip.amount)}, thank you!`
+            : "The rider can now leave you a tip."
+        }
+      />
+    );
+  }
+
   return null;
 };
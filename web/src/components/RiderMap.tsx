@@ -15,6 +15,7 @@ import { useMemo, useRef, useState } from "react";
 import { MapClickHandler } from "./MapClickHandler";
 import { Button } from "./ui/button";
 import {
+  Money,
   RouteFare,
   RequestRideProps,
   TripPreview,
@@ -28,6 +29,9 @@ import {
   HTTPTripPreviewRequestPayload,
   HTTPTripPreviewResponse,
   HTTPTripStartRequestPayload,
+  HTTPTripTipRequestPayload,
+  HTTPSetupPaymentMethodRequestPayload,
+  HTTPSetupPaymentMethodResponse,
 } from "../contracts";
 
 const userMarker = new L.Icon({
@@ -71,6 +75,8 @@ export default function RiderMap({ onRouteSelected }: RiderMapProps) {
     tripStatus,
     assignedDriver,
     paymentSession,
+    scheduledCharge,
+    completedTrip,
     resetTripStatus,
   } = useRiderStreamConnection(location, userId);
 
@@ -167,6 +173,50 @@ export default function RiderMap({ onRouteSelected }: RiderMapProps) {
     return data;
   };
 
+  const handleTip = async (amount: Money) => {
+    if (!completedTrip?.id) {
+      return;
+    }
+
+    const payload = {
+      tripId: completedTrip.id,
+      userId: userId,
+      amount,
+    } as HTTPTripTipRequestPayload;
+
+    // the tip's checkout session arrives over the websocket once it is created
+    const response = await fetch(`${API_URL}${BackendEndpoints.TIP_TRIP}`, {
+      method: "POST",
+      body: JSON.stringify(payload),
+    });
+
+    if (!response.ok) {
+      const { error } = await response.json();
+      alert(error?.message ?? "Failed to send the tip");
+    }
+  };
+
+  const handleSavePaymentMethod = async () => {
+    const payload = {
+      userId: userId,
+    } as HTTPSetupPaymentMethodRequestPayload;
+
+    const response = await fetch(`${API_URL}${BackendEndpoints.SETUP_PAYMENT_METHOD}`, {
+      method: "POST",
+      body: JSON.stringify(payload),
+    });
+
+    if (!response.ok) {
+      const { error } = await response.json();
+      alert(error?.message ?? "Failed to start saving the card");
+      return;
+    }
+
+    // the rider id only lives as long as this page, the card is saved in another tab
+    const { data } = (await response.json()) as { data: HTTPSetupPaymentMethodResponse };
+    window.open(data.setupUrl, "_blank");
+  };
+
   const handleCancelTrip = () => {
     setTrip(null);
     setDestination(null);
@@ -264,7 +314,11 @@ export default function RiderMap({ onRouteSelected }: RiderMapProps) {
           assignedDriver={assignedDriver}
           status={tripStatus}
           paymentSession={paymentSession}
+          scheduledCharge={scheduledCharge}
+          completedTrip={completedTrip}
           onPackageSelect={handleStartTrip}
+          onTip={handleTip}
+          onSavePaymentMethod={handleSavePaymentMethod}
           onCancel={handleCancelTrip}
         />
       </div>
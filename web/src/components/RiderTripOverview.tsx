@@ -1,4 +1,4 @@
-import { RouteFare, TripPreview, Driver } from "../types";
+import { RouteFare, TripPreview, Driver, Trip, Money } from "../types";
 import { DriverList } from "./DriversList";
 import { Card } from "./ui/card";
 import { Button } from "./ui/button";
@@ -10,15 +10,24 @@ import { Skeleton } from "./ui/skeleton";
 import { TripOverviewCard } from "./TripOverviewCard";
 import { StripePaymentButton } from "./StripePaymentButton";
 import { DriverCard } from "./DriverCard";
-import { TripEvents, PaymentEventSessionCreatedData } from "../contracts";
+import {
+  TripEvents,
+  PaymentEventSessionCreatedData,
+  PaymentChargeScheduledData,
+} from "../contracts";
 import { useEffect, useState } from "react";
+import { formatMoney, tipOptions } from "../utils/money";
 
 interface TripOverviewProps {
   trip: TripPreview | null;
   status: TripEvents | null;
   assignedDriver?: Driver | null;
   paymentSession?: PaymentEventSessionCreatedData | null;
+  scheduledCharge?: PaymentChargeScheduledData | null;
+  completedTrip?: Trip | null;
   onPackageSelect: (carPackage: RouteFare) => void;
+  onTip?: (amount: Money) => void;
+  onSavePaymentMethod?: () => void;
   onCancel: () => void;
 }
 
@@ -27,7 +36,11 @@ export const RiderTripOverview = ({
   status,
   assignedDriver,
   paymentSession,
+  scheduledCharge,
+  completedTrip,
   onPackageSelect,
+  onTip,
+  onSavePaymentMethod,
   onCancel,
 }: TripOverviewProps) => {
   const [delayDone, setDelayDone] = useState(false);
@@ -49,22 +62,35 @@ export const RiderTripOverview = ({
       <TripOverviewCard
         title="Start a trip"
         description="Click on the map to set a destination"
-      />
+      >
+        {onSavePaymentMethod && (
+          <Button variant="outline" className="w-full" onClick={onSavePaymentMethod}>
+            Save a card to pay automatically
+          </Button>
+        )}
+      </TripOverviewCard>
     );
   }
 
   if (status === TripEvents.PaymentSessionCreated && paymentSession) {
     return (
       <TripOverviewCard
-        title="Payment Required"
-        description="Please complete the payment to confirm your trip"
+        title={paymentSession.tipId ? "Tip your driver" : "Payment Required"}
+        description={
+          paymentSession.tipId
+            ? "Complete the payment to send your tip to the driver"
+            : "Please complete the payment to confirm your trip"
+        }
       >
         <div className="flex flex-col gap-4">
           <DriverCard driver={assignedDriver} />
 
           <div className="text-sm text-gray-500">
+            {paymentSession.reason && (
+              <p>Your saved card could not be charged: {paymentSession.reason}</p>
+            )}
             <p>
-              Amount: {paymentSession.amount} {paymentSession.currency}
+              Amount: {formatMoney(paymentSession.amount)}
             </p>
             <p>Trip ID: {paymentSession.tripId}</p>
           </div>
@@ -74,6 +100,26 @@ export const RiderTripOverview = ({
     );
   }
 
+  if (status === TripEvents.PaymentChargeScheduled && scheduledCharge) {
+    return (
+      <TripOverviewCard
+        title="Your driver is on the way"
+        description={`Your ${scheduledCharge.paymentMethod.brand} card ending in ${scheduledCharge.paymentMethod.last4} will be charged when the trip ends`}
+      >
+        <div className="flex flex-col gap-4">
+          <DriverCard driver={assignedDriver} />
+
+          <div className="text-sm text-gray-500">
+            <p>
+              Amount: {formatMoney(scheduledCharge.amount)}
+            </p>
+            <p>Trip ID: {scheduledCharge.tripId}</p>
+          </div>
+        </div>
+      </TripOverviewCard>
+    );
+  }
+
   if (status === TripEvents.NoDriversFound) {
     return (
       <TripOverviewCard
@@ -109,9 +155,28 @@ export const RiderTripOverview = ({
         title="Trip completed!"
         description="Your trip is completed, thank you for using our service!"
       >
-        <Button variant="outline" className="w-full" onClick={onCancel}>
-          Go back
-        </Button>
+        <div className="flex flex-col gap-2">
+          {completedTrip?.selectedFare?.price && onTip && (
+            <>
+              <p className="text-sm text-gray-500">Leave a tip for your driver</p>
+              <div className="flex gap-2">
+                {tipOptions(completedTrip.selectedFare.price).map((tip) => (
+                  <Button
+                    key={tip.amount}
+                    variant="outline"
+                    className="flex-1"
+                    onClick={() => onTip(tip)}
+                  >
+                    {formatMoney(tip)}
+                  </Button>
+                ))}
+              </div>
+            </>
+          )}
+          <Button variant="outline" className="w-full" onClick={onCancel}>
+            Go back
+          </Button>
+        </div>
       </TripOverviewCard>
     );
   }
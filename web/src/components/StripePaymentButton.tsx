@@ -1,5 +1,6 @@
 import { PaymentEventSessionCreatedData } from "../contracts";
 import { Button } from "./ui/button";
+import { formatMoney } from "../utils/money";
 import { loadStripe } from "@stripe/stripe-js";
 
 interface StripePaymentButtonProps {
@@ -17,6 +18,12 @@ export const StripePaymentButton = ({
   isLoading = false,
 }: StripePaymentButtonProps) => {
   const handlePayment = async () => {
+    // hosted checkout page (e.g. the fake processor used for local development)
+    if (paymentSession.checkoutUrl) {
+      window.location.assign(paymentSession.checkoutUrl);
+      return;
+    }
+
     const stripe = await stripePromise;
 
     if (!stripe) {
@@ -34,7 +41,10 @@ export const StripePaymentButton = ({
     }
   };
 
-  if (!process.env.NEXT_PUBLIC_STRIPE_PUBLISHABLE_KEY) {
+  if (
+    !paymentSession.checkoutUrl &&
+    !process.env.NEXT_PUBLIC_STRIPE_PUBLISHABLE_KEY
+  ) {
     return (
       <Button disabled className="w-full bg-red-500 text-white">
         Stripe API KEY is not set on the NEXTJS app
@@ -46,7 +56,7 @@ export const StripePaymentButton = ({
     <Button onClick={handlePayment} disabled={isLoading} className="w-full">
       {isLoading
         ? "Loading..."
-        : `Pay ${paymentSession.amount} ${paymentSession.currency}`}
+        : `Pay ${formatMoney(paymentSession.amount)}`}
     </Button>
   );
 };
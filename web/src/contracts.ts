@@ -1,9 +1,11 @@
-import { Coordinate, Driver, Route, RouteFare, Trip } from "./types";
+import { Coordinate, Driver, Money, Route, RouteFare, Trip } from "./types";
 
 // These are the endpoints the API Gateway must have for the frontend to work correctly
 export enum BackendEndpoints {
   PREVIEW_TRIP = "/trip/preview",
   START_TRIP = "/trip/start",
+  TIP_TRIP = "/trip/tip",
+  SETUP_PAYMENT_METHOD = "/payment-methods/setup",
   WS_DRIVERS = "/drivers",
   WS_RIDERS = "/riders",
 }
@@ -18,28 +20,60 @@ export enum TripEvents {
   DriverTripRequest = "driver.cmd.trip_request",
   DriverTripAccept = "driver.cmd.trip_accept",
   DriverTripDecline = "driver.cmd.trip_decline",
+  DriverTripComplete = "driver.cmd.trip_complete",
   DriverRegister = "driver.cmd.register",
   PaymentSessionCreated = "payment.event.session_created",
+  PaymentChargeScheduled = "payment.event.charge_scheduled",
+  PaymentSuccess = "payment.event.success",
+  PaymentFailed = "payment.event.failed",
+  PaymentCancelled = "payment.event.cancelled",
+  PaymentRefunded = "payment.event.refunded",
+  PaymentTipReceived = "payment.event.tip_received",
+  PaymentTipFailed = "payment.event.tip_failed",
 }
 
 // Messages sent from the server to the client via the websocket
 export type ServerWsMessage =
   | PaymentSessionCreatedRequest
+  | PaymentChargeScheduledRequest
   | DriverAssignedRequest
   | DriverLocationRequest
   | DriverTripRequest
   | DriverRegisterRequest
   | TripCreatedRequest
+  | TripCompletedRequest
+  | TipReceivedRequest
   | NoDriversFoundRequest;
 
 // Messages sent from the client to the server via the websocket
-export type ClientWsMessage = DriverResponseToTripResponse;
+export type ClientWsMessage =
+  | DriverResponseToTripResponse
+  | DriverTripCompleteRequest;
 
 interface TripCreatedRequest {
   type: TripEvents.Created;
   data: Trip;
 }
 
+interface TripCompletedRequest {
+  type: TripEvents.Completed;
+  data: { trip: Trip };
+}
+
+export interface PaymentTipData {
+  tripId: string;
+  tipId: string;
+  userId: string;
+  driverId: string;
+  amount: Money;
+  reason?: string;
+}
+
+interface TipReceivedRequest {
+  type: TripEvents.PaymentTipReceived;
+  data: PaymentTipData;
+}
+
 interface NoDriversFoundRequest {
   type: TripEvents.NoDriversFound;
 }
@@ -56,8 +90,27 @@ interface DriverTripRequest {
 export interface PaymentEventSessionCreatedData {
   tripId: string;
   sessionId: string;
-  amount: number;
-  currency: string;
+  checkoutUrl?: string;
+  amount: Money;
+  tipId?: string; // set when the session charges a tip rather than the fare
+  reason?: string; // set when the saved payment method could not be charged
+}
+
+export interface PaymentChargeScheduledData {
+  tripId: string;
+  userId: string;
+  driverId: string;
+  amount: Money;
+  paymentMethod: {
+    id: string;
+    brand: string;
+    last4: string;
+  };
+}
+
+interface PaymentChargeScheduledRequest {
+  type: TripEvents.PaymentChargeScheduled;
+  data: PaymentChargeScheduledData;
 }
 
 interface PaymentSessionCreatedRequest {
@@ -84,6 +137,28 @@ interface DriverResponseToTripResponse {
   };
 }
 
+interface DriverTripCompleteRequest {
+  type: TripEvents.DriverTripComplete;
+  data: {
+    tripId: string;
+  };
+}
+
+export interface HTTPTripTipRequestPayload {
+  tripId: string;
+  userId: string;
+  amount: Money;
+}
+
+export interface HTTPSetupPaymentMethodRequestPayload {
+  userId: string;
+}
+
+export interface HTTPSetupPaymentMethodResponse {
+  sessionId: string;
+  setupUrl: string;
+}
+
 export interface HTTPTripPreviewResponse {
   route: Route;
   rideFares: RouteFare[];
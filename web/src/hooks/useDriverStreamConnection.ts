@@ -8,6 +8,7 @@ import {
   isValidTripEvent,
   ClientWsMessage,
   BackendEndpoints,
+  PaymentTipData,
 } from "../contracts";
 
 interface useDriverConnectionProps {
@@ -31,6 +32,7 @@ export const useDriverStreamConnection = ({
   const [error, setError] = useState<string | null>(null);
   const [ws, setWs] = useState<WebSocket | null>(null);
   const [driver, setDriver] = useState<Driver | null>(null);
+  const [tip, setTip] = useState<PaymentTipData | null>(null);
 
   useEffect(() => {
     if (!userId) return;
@@ -75,6 +77,9 @@ export const useDriverStreamConnection = ({
         case TripEvents.DriverRegister:
           setDriver(message.data);
           break;
+        case TripEvents.PaymentTipReceived:
+          setTip(message.data);
+          break;
       }
 
       if (isValidTripEvent(message.type)) {
@@ -117,6 +122,7 @@ export const useDriverStreamConnection = ({
   const resetTripStatus = () => {
     setTripStatus(null);
     setRequestedTrip(null);
+    setTip(null);
   };
 
   return {
@@ -124,6 +130,7 @@ export const useDriverStreamConnection = ({
     tripStatus,
     driver,
     requestedTrip,
+    tip,
     resetTripStatus,
     sendMessage,
     setTripStatus,
@@ -2,13 +2,15 @@ import { useEffect, useState } from 'react';
 import { WEBSOCKET_URL } from "../constants";
 import { Trip } from '../types';
 import { Driver, Coordinate } from '../types';
-import { PaymentEventSessionCreatedData, TripEvents, ServerWsMessage, isValidWsMessage, BackendEndpoints } from '../contracts';
+import { PaymentEventSessionCreatedData, PaymentChargeScheduledData, TripEvents, ServerWsMessage, isValidWsMessage, BackendEndpoints } from '../contracts';
 
 export function useRiderStreamConnection(location: Coordinate, userID: string) {
   const [drivers, setDrivers] = useState<Driver[]>([]);
   const [tripStatus, setTripStatus] = useState<TripEvents | null>(null);
   const [paymentSession, setPaymentSession] = useState<PaymentEventSessionCreatedData | null>(null);
+  const [scheduledCharge, setScheduledCharge] = useState<PaymentChargeScheduledData | null>(null);
   const [assignedDriver, setAssignedDriver] = useState<Trip["driver"] | null>(null);
+  const [completedTrip, setCompletedTrip] = useState<Trip | null>(null);
   const [error, setError] = useState<string | null>(null);
 
   useEffect(() => {
@@ -44,6 +46,10 @@ export function useRiderStreamConnection(location: Coordinate, userID: string) {
           setPaymentSession(message.data);
           setTripStatus(message.type);
           break;
+        case TripEvents.PaymentChargeScheduled:
+          setScheduledCharge(message.data);
+          setTripStatus(message.type);
+          break;
         case TripEvents.DriverAssigned:
           setAssignedDriver(message.data.driver);
           setTripStatus(message.type);
@@ -51,6 +57,10 @@ export function useRiderStreamConnection(location: Coordinate, userID: string) {
         case TripEvents.Created:
           setTripStatus(message.type);
           break;
+        case TripEvents.Completed:
+          setCompletedTrip(message.data.trip);
+          setTripStatus(message.type);
+          break;
         case TripEvents.NoDriversFound:
           setTripStatus(message.type);
           break;
@@ -78,7 +88,9 @@ export function useRiderStreamConnection(location: Coordinate, userID: string) {
   const resetTripStatus = () => {
     setTripStatus(null);
     setPaymentSession(null);
+    setScheduledCharge(null);
+    setCompletedTrip(null);
   }
 
-  return { drivers, assignedDriver, error, tripStatus, paymentSession, resetTripStatus };
+  return { drivers, assignedDriver, error, tripStatus, paymentSession, scheduledCharge, completedTrip, resetTripStatus };
 }
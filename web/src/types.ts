@@ -33,11 +33,17 @@ export enum CarPackageSlug {
   LUXURY = "luxury",
 }
 
+// amount is in the minor unit of the currency, e.g. cents
+export interface Money {
+  amount: number;
+  currency: string;
+}
+
 export interface RouteFare {
   id: string;
   packageSlug: CarPackageSlug;
   basePrice: number;
-  totalPriceInCents?: number;
+  price?: Money;
   expiresAt: Date;
   route: Route;
 }
@@ -0,0 +1,32 @@
+import { Money } from "../types"
+
+// ISO 4217 currencies whose minor unit is not a hundredth, mirrors CurrencyExponent in shared/types
+const CURRENCY_EXPONENTS: Record<string, number> = {
+  BIF: 0, CLP: 0, DJF: 0, GNF: 0, ISK: 0, JPY: 0, KMF: 0, KRW: 0,
+  PYG: 0, RWF: 0, UGX: 0, VND: 0, VUV: 0, XAF: 0, XOF: 0, XPF: 0,
+  BHD: 3, JOD: 3, KWD: 3, OMR: 3, TND: 3,
+}
+
+export function currencyExponent(currency: string) {
+  return CURRENCY_EXPONENTS[currency.toUpperCase()] ?? 2
+}
+
+// formatMoney renders an amount kept in minor units, e.g. 1234 USD as $12.34 and 25000 VND as ₫25,000
+export function formatMoney(money: Money) {
+  const exponent = currencyExponent(money.currency)
+
+  return new Intl.NumberFormat(undefined, {
+    style: "currency",
+    currency: money.currency,
+    minimumFractionDigits: exponent,
+    maximumFractionDigits: exponent,
+  }).format(money.amount / 10 ** exponent)
+}
+
+// tipOptions suggests tips as a share of the fare, in the fare's currency
+export function tipOptions(fare: Money, percentages = [10, 15, 20]): Money[] {
+  return percentages.map((percentage) => ({
+    amount: Math.max(1, Math.round((fare.amount * percentage) / 100)),
+    currency: fare.currency,
+  }))
+}